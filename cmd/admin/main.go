@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	store "github.com/arashalaei/go-clean-socket-architecture/internal/repository/sqlite"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/person"
+	"github.com/arashalaei/go-clean-socket-architecture/pkg/config"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+// createAdmin adds an admin straight to the database, this is how the
+// first admin is created once the server requires logins.
+func createAdmin(cfg *config.Config, name string) {
+	db, err := store.NewSqlite(cfg.Database.Path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prompt := promptui.Prompt{
+		Label: "Password",
+		Mask:  '*',
+	}
+	password, err := prompt.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// whoever can run this command owns the database
+	ctx := entity.WithActor(context.Background(), entity.Actor{Role: entity.AdminRole})
+
+	createUseCase := person.NewCreatePersonUseCase(db, event.NewBus())
+	personId, err := createUseCase.Execute(ctx, entity.Person{
+		Name: name,
+		Role: entity.AdminRole,
+	}, password)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Admin created, log in with person ID %d\n", personId)
+}
+
+func Register(root *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "create an admin",
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := cmd.Flags().GetString("config")
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			name, err := cmd.Flags().GetString("name")
+			if err != nil {
+				log.Fatal(err)
+			}
+			if strings.TrimSpace(name) == "" {
+				log.Fatal("name cannot be empty")
+			}
+
+			cfg, err := config.Load(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			createAdmin(cfg, name)
+		},
+	}
+
+	cmd.Flags().StringP("config", "c", "", "The config path")
+	cmd.Flags().StringP("name", "n", "", "The admin name")
+	cmd.MarkFlagRequired("config")
+	cmd.MarkFlagRequired("name")
+	root.AddCommand(cmd)
+}
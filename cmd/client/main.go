@@ -3,7 +3,6 @@ package client
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -11,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
@@ -52,7 +52,12 @@ func runMainMenu(client *tcp.Client) {
 				"1. School",
 				"2. Class",
 				"3. Person",
-				"4. Exit",
+				"4. Search",
+				"5. Watch Changes",
+				"6. Login",
+				"7. Logout",
+				"8. Purge Deleted Records (admin)",
+				"9. Exit",
 			},
 		}
 
@@ -70,6 +75,16 @@ func runMainMenu(client *tcp.Client) {
 		case 2:
 			runPersonMenu(client)
 		case 3:
+			handleSearch(client)
+		case 4:
+			handleWatchChanges(client)
+		case 5:
+			handleLogin(client)
+		case 6:
+			handleLogout(client)
+		case 7:
+			handlePurge(client)
+		case 8:
 			fmt.Println("Exiting...")
 			return
 		default:
@@ -85,7 +100,11 @@ func runSchoolMenu(client *tcp.Client) {
 			Items: []string{
 				"1. Add New School",
 				"2. List All Schools",
-				"3. Back to Main Menu",
+				"3. List Deleted Schools Too (admin)",
+				"4. Rename School",
+				"5. Delete School",
+				"6. Restore School (admin)",
+				"7. Back to Main Menu",
 			},
 		}
 
@@ -99,8 +118,16 @@ func runSchoolMenu(client *tcp.Client) {
 		case 0:
 			handleCreateSchool(client)
 		case 1:
-			handleListSchools(client)
+			handleListSchools(client, false)
 		case 2:
+			handleListSchools(client, true)
+		case 3:
+			handleUpdateSchool(client)
+		case 4:
+			handleByID(client, tcp.DeleteSchool, "school", "deleting")
+		case 5:
+			handleByID(client, tcp.RestoreSchool, "school", "restoring")
+		case 6:
 			return
 		default:
 			return
@@ -115,8 +142,13 @@ func runClassMenu(client *tcp.Client) {
 			Items: []string{
 				"1. Add New Class",
 				"2. List All Classes",
-				"3. Add Student To Class",
-				"4. Back to Main Menu",
+				"3. List Deleted Classes Too (admin)",
+				"4. Add Student To Class",
+				"5. Import Roster",
+				"6. Update Class",
+				"7. Delete Class",
+				"8. Restore Class (admin)",
+				"9. Back to Main Menu",
 			},
 		}
 
@@ -130,10 +162,20 @@ func runClassMenu(client *tcp.Client) {
 		case 0:
 			handleCreateClass(client)
 		case 1:
-			handleListClasses(client)
+			handleListClasses(client, false)
 		case 2:
-			handleAddStudentToClass(client)
+			handleListClasses(client, true)
 		case 3:
+			handleAddStudentToClass(client)
+		case 4:
+			handleImportRoster(client)
+		case 5:
+			handleUpdateClass(client)
+		case 6:
+			handleByID(client, tcp.DeleteClass, "class", "deleting")
+		case 7:
+			handleByID(client, tcp.RestoreClass, "class", "restoring")
+		case 8:
 			return
 		default:
 			return
@@ -148,8 +190,15 @@ func runPersonMenu(client *tcp.Client) {
 			Items: []string{
 				"1. Add New Person",
 				"2. List All Persons",
-				"3. Who Am I?",
-				"4. Back to Main Menu",
+				"3. List Deleted Persons Too (admin)",
+				"4. Who Am I?",
+				"5. Change My Password",
+				"6. Set Password (admin)",
+				"7. Reset Password (admin)",
+				"8. Update Person (admin)",
+				"9. Delete Person (admin)",
+				"10. Restore Person (admin)",
+				"11. Back to Main Menu",
 			},
 		}
 
@@ -163,10 +212,24 @@ func runPersonMenu(client *tcp.Client) {
 		case 0:
 			handleCreatePerson(client)
 		case 1:
-			handleListPersons(client)
+			handleListPersons(client, false)
 		case 2:
-			handleWhoAmI(client)
+			handleListPersons(client, true)
 		case 3:
+			handleWhoAmI(client)
+		case 4:
+			handleChangePassword(client)
+		case 5:
+			handleSetPassword(client, tcp.SetPassword)
+		case 6:
+			handleSetPassword(client, tcp.ResetPassword)
+		case 7:
+			handleUpdatePerson(client)
+		case 8:
+			handleByID(client, tcp.DeletePerson, "person", "deleting")
+		case 9:
+			handleByID(client, tcp.RestorePerson, "person", "restoring")
+		case 10:
 			return
 		default:
 			return
@@ -193,40 +256,118 @@ func handleCreateSchool(client *tcp.Client) {
 		fmt.Printf("Error creating school: %v\n", err)
 		return
 	}
-	fmt.Printf("School created successfully: %+v\n", res.Data)
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error creating school: %v\n", err)
+		return
+	}
+
+	var schoolId uint
+	if err := client.Decode(res, &schoolId); err != nil {
+		fmt.Printf("Error parsing school id: %v\n", err)
+		return
+	}
+	fmt.Printf("School created successfully: %d\n", schoolId)
 }
 
-func handleListSchools(client *tcp.Client) {
-	res, err := client.Send(
-		context.Background(),
-		tcp.ListSchools, "")
+func handleListSchools(client *tcp.Client, includeDeleted bool) {
+	var schools []dto.School
+	for item, err := range tcp.StreamItems[dto.School](
+		context.Background(), client,
+		tcp.StreamSchools, dto.StreamReq{IncludeDeleted: includeDeleted}) {
+		if err != nil {
+			fmt.Printf("Error listing schools: %v\n", err)
+			return
+		}
+		schools = append(schools, item)
+	}
+
+	if len(schools) == 0 {
+		fmt.Println("No schools found.")
+		return
+	}
+
+	printSchoolsTable(schools)
+}
+
+func handleUpdateSchool(client *tcp.Client) {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	fmt.Println("Enter the school ID:")
+	scanner.Scan()
+	schoolIdStr := strings.TrimSpace(scanner.Text())
+	schoolId, err := strconv.ParseUint(schoolIdStr, 10, 32)
 	if err != nil {
-		fmt.Printf("Error listing schools: %v\n", err)
+		fmt.Printf("Invalid school ID: %v\n", err)
 		return
 	}
 
-	dataBytes, err := json.Marshal(res.Data)
+	fmt.Println("Enter the new school name:")
+	scanner.Scan()
+	name := strings.TrimSpace(scanner.Text())
+	if name == "" {
+		fmt.Println("School name cannot be empty")
+		return
+	}
+
+	res, err := client.Send(
+		context.Background(),
+		tcp.UpdateSchool,
+		dto.UpdateSchoolReq{
+			Id:   uint(schoolId),
+			Name: name,
+		},
+	)
 	if err != nil {
-		fmt.Printf("Error parsing schools data: %v\n", err)
+		fmt.Printf("Error updating school: %v\n", err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error updating school: %v\n", err)
 		return
 	}
 
-	var schools []struct {
-		Id   uint   `json:"Id"`
-		Name string `json:"Name"`
+	var school dto.School
+	if err := client.Decode(res, &school); err != nil {
+		fmt.Printf("Error parsing school: %v\n", err)
+		return
 	}
+	printSchoolsTable([]dto.School{school})
+}
+
+// handleByID sends one of the delete_* or restore_* requests, what names
+// the record and doing the action in prompts and messages.
+func handleByID(client *tcp.Client, reqType tcp.RequestType, what, doing string) {
+	scanner := bufio.NewScanner(os.Stdin)
 
-	if err := json.Unmarshal(dataBytes, &schools); err != nil {
-		fmt.Printf("Error unmarshaling schools: %v\n", err)
+	fmt.Printf("Enter the %s ID:\n", what)
+	scanner.Scan()
+	idStr := strings.TrimSpace(scanner.Text())
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		fmt.Printf("Invalid %s ID: %v\n", what, err)
 		return
 	}
 
-	if len(schools) == 0 {
-		fmt.Println("No schools found.")
+	res, err := client.Send(
+		context.Background(),
+		reqType,
+		dto.IdReq{Id: uint(id)},
+	)
+	if err != nil {
+		fmt.Printf("Error %s %s: %v\n", doing, what, err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error %s %s: %v\n", doing, what, err)
 		return
 	}
 
-	printSchoolsTable(schools)
+	var message string
+	if err := client.Decode(res, &message); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	fmt.Printf("%s\n", message)
 }
 
 func handleCreateClass(client *tcp.Client) {
@@ -271,33 +412,29 @@ func handleCreateClass(client *tcp.Client) {
 		fmt.Printf("Error creating class: %v\n", err)
 		return
 	}
-	fmt.Printf("Class created successfully: %+v\n", res.Data)
-}
-
-func handleListClasses(client *tcp.Client) {
-	res, err := client.Send(
-		context.Background(),
-		tcp.ListClasses, "")
-	if err != nil {
-		fmt.Printf("Error listing classes: %v\n", err)
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error creating class: %v\n", err)
 		return
 	}
 
-	dataBytes, err := json.Marshal(res.Data)
-	if err != nil {
-		fmt.Printf("Error parsing classes data: %v\n", err)
+	var classId uint
+	if err := client.Decode(res, &classId); err != nil {
+		fmt.Printf("Error parsing class id: %v\n", err)
 		return
 	}
+	fmt.Printf("Class created successfully: %d\n", classId)
+}
 
-	var classes []struct {
-		Id       uint   `json:"Id"`
-		Name     string `json:"Name"`
-		SchoolId uint   `json:"SchoolId"`
-	}
-
-	if err := json.Unmarshal(dataBytes, &classes); err != nil {
-		fmt.Printf("Error unmarshaling classes: %v\n", err)
-		return
+func handleListClasses(client *tcp.Client, includeDeleted bool) {
+	var classes []dto.Class
+	for item, err := range tcp.StreamItems[dto.Class](
+		context.Background(), client,
+		tcp.StreamClasses, dto.StreamReq{IncludeDeleted: includeDeleted}) {
+		if err != nil {
+			fmt.Printf("Error listing classes: %v\n", err)
+			return
+		}
+		classes = append(classes, item)
 	}
 
 	if len(classes) == 0 {
@@ -341,7 +478,160 @@ func handleAddStudentToClass(client *tcp.Client) {
 		fmt.Printf("Error adding student to class: %v\n", err)
 		return
 	}
-	fmt.Printf("%v\n", res.Data)
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error adding student to class: %v\n", err)
+		return
+	}
+
+	var message string
+	if err := client.Decode(res, &message); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	fmt.Printf("%s\n", message)
+}
+
+func handleUpdateClass(client *tcp.Client) {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	fmt.Println("Enter the class ID:")
+	scanner.Scan()
+	classIdStr := strings.TrimSpace(scanner.Text())
+	classId, err := strconv.ParseUint(classIdStr, 10, 32)
+	if err != nil {
+		fmt.Printf("Invalid class ID: %v\n", err)
+		return
+	}
+
+	fmt.Println("Enter the new class name (leave empty to keep it):")
+	scanner.Scan()
+	name := strings.TrimSpace(scanner.Text())
+
+	fmt.Println("Enter the new teacher ID (leave empty to keep it):")
+	teacherId, err := readOptionalId(scanner)
+	if err != nil {
+		fmt.Printf("Invalid teacher ID: %v\n", err)
+		return
+	}
+
+	res, err := client.Send(
+		context.Background(),
+		tcp.UpdateClass,
+		dto.UpdateClassReq{
+			Id:        uint(classId),
+			Name:      name,
+			TeacherId: teacherId,
+		},
+	)
+	if err != nil {
+		fmt.Printf("Error updating class: %v\n", err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error updating class: %v\n", err)
+		return
+	}
+
+	var class dto.Class
+	if err := client.Decode(res, &class); err != nil {
+		fmt.Printf("Error parsing class: %v\n", err)
+		return
+	}
+	printClassesTable([]dto.Class{class})
+}
+
+func handleImportRoster(client *tcp.Client) {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	fmt.Println("Enter the class ID:")
+	scanner.Scan()
+	classIdStr := strings.TrimSpace(scanner.Text())
+	classId, err := strconv.ParseUint(classIdStr, 10, 32)
+	if err != nil {
+		fmt.Printf("Invalid class ID: %v\n", err)
+		return
+	}
+
+	fmt.Println("Enter the school ID:")
+	scanner.Scan()
+	schoolIdStr := strings.TrimSpace(scanner.Text())
+	schoolId, err := strconv.ParseUint(schoolIdStr, 10, 32)
+	if err != nil {
+		fmt.Printf("Invalid school ID: %v\n", err)
+		return
+	}
+
+	fmt.Println("Enter the student names, separated by commas:")
+	scanner.Scan()
+	var names []string
+	for _, name := range strings.Split(scanner.Text(), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Println("No student names given")
+		return
+	}
+
+	var creates []tcp.BatchItem
+	for _, name := range names {
+		creates = append(creates, tcp.BatchItem{
+			Type: tcp.CreatePerson,
+			Payload: dto.CreatePersonReq{
+				Name:     name,
+				Role:     "student",
+				SchoolId: uint(schoolId),
+			},
+		})
+	}
+
+	results, err := client.Batch(context.Background(), true, creates...)
+	if err == nil {
+		err = batchError(results)
+	}
+	if err != nil {
+		fmt.Printf("Error creating students: %v\n", err)
+		return
+	}
+
+	var adds []tcp.BatchItem
+	for _, res := range results {
+		var studentId uint
+		if err := client.Decode(res, &studentId); err != nil {
+			fmt.Printf("Error parsing student id: %v\n", err)
+			return
+		}
+		adds = append(adds, tcp.BatchItem{
+			Type: tcp.AddStudentToClass,
+			Payload: dto.AddStudentToClassReq{
+				ClassId:   uint(classId),
+				StudentId: studentId,
+			},
+		})
+	}
+
+	results, err = client.Batch(context.Background(), true, adds...)
+	if err == nil {
+		err = batchError(results)
+	}
+	if err != nil {
+		fmt.Printf("Students were created but not added to the class: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Imported %d students into class %d\n", len(names), classId)
+}
+
+// batchError returns the error of the item that failed a batch. Items an
+// atomic batch rolled back or skipped because of it are ignored.
+func batchError(results []*dto.Response) error {
+	for _, res := range results {
+		if err := tcp.ResponseError(res); err != nil && !tcp.HasCode(err, tcp.CodeAborted) {
+			return err
+		}
+	}
+	return nil
 }
 
 func handleCreatePerson(client *tcp.Client) {
@@ -355,11 +645,11 @@ func handleCreatePerson(client *tcp.Client) {
 		return
 	}
 
-	fmt.Println("Enter the role (student/teacher):")
+	fmt.Println("Enter the role (student/teacher/admin):")
 	scanner.Scan()
 	role := strings.TrimSpace(scanner.Text())
-	if role != "student" && role != "teacher" {
-		fmt.Println("Role must be 'student' or 'teacher'")
+	if role != "student" && role != "teacher" && role != "admin" {
+		fmt.Println("Role must be 'student', 'teacher' or 'admin'")
 		return
 	}
 
@@ -372,6 +662,12 @@ func handleCreatePerson(client *tcp.Client) {
 		return
 	}
 
+	password, err := readPassword("Password (leave empty for none)")
+	if err != nil {
+		fmt.Printf("Prompt failed %v\n", err)
+		return
+	}
+
 	res, err := client.Send(
 		context.Background(),
 		tcp.CreatePerson,
@@ -379,54 +675,183 @@ func handleCreatePerson(client *tcp.Client) {
 			Name:     name,
 			Role:     role,
 			SchoolId: uint(schoolId),
+			Password: password,
 		},
 	)
 	if err != nil {
 		fmt.Printf("Error creating person: %v\n", err)
 		return
 	}
-	fmt.Printf("Person created successfully: %+v\n", res.Data)
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error creating person: %v\n", err)
+		return
+	}
+
+	var personId uint
+	if err := client.Decode(res, &personId); err != nil {
+		fmt.Printf("Error parsing person id: %v\n", err)
+		return
+	}
+	fmt.Printf("Person created successfully: %d\n", personId)
+}
+
+func handleListPersons(client *tcp.Client, includeDeleted bool) {
+	var persons []dto.Person
+	for item, err := range tcp.StreamItems[dto.Person](
+		context.Background(), client,
+		tcp.StreamPersons, dto.StreamReq{IncludeDeleted: includeDeleted}) {
+		if err != nil {
+			fmt.Printf("Error listing persons: %v\n", err)
+			return
+		}
+		persons = append(persons, item)
+	}
+
+	if len(persons) == 0 {
+		fmt.Println("No persons found.")
+		return
+	}
+
+	printPersonsTable(persons)
 }
 
-func handleListPersons(client *tcp.Client) {
+func handleUpdatePerson(client *tcp.Client) {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	fmt.Println("Enter the person ID:")
+	scanner.Scan()
+	personIdStr := strings.TrimSpace(scanner.Text())
+	personId, err := strconv.ParseUint(personIdStr, 10, 32)
+	if err != nil {
+		fmt.Printf("Invalid person ID: %v\n", err)
+		return
+	}
+
+	fmt.Println("Enter the new person name (leave empty to keep it):")
+	scanner.Scan()
+	name := strings.TrimSpace(scanner.Text())
+
+	fmt.Println("Enter the new school ID (leave empty to keep it):")
+	schoolId, err := readOptionalId(scanner)
+	if err != nil {
+		fmt.Printf("Invalid school ID: %v\n", err)
+		return
+	}
+
 	res, err := client.Send(
 		context.Background(),
-		tcp.ListPersons, "")
+		tcp.UpdatePerson,
+		dto.UpdatePersonReq{
+			Id:       uint(personId),
+			Name:     name,
+			SchoolId: schoolId,
+		},
+	)
 	if err != nil {
-		fmt.Printf("Error listing persons: %v\n", err)
+		fmt.Printf("Error updating person: %v\n", err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error updating person: %v\n", err)
 		return
 	}
 
-	dataBytes, err := json.Marshal(res.Data)
+	var person dto.Person
+	if err := client.Decode(res, &person); err != nil {
+		fmt.Printf("Error unmarshaling person: %v\n", err)
+		return
+	}
+	printPersonDetails(person)
+}
+
+func handleWhoAmI(client *tcp.Client) {
+	res, err := client.Send(
+		context.Background(),
+		tcp.WhoAmI,
+		"",
+	)
 	if err != nil {
-		fmt.Printf("Error parsing persons data: %v\n", err)
+		fmt.Printf("Error getting person info: %v\n", err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		if tcp.HasCode(err, tcp.CodeUnauthenticated) {
+			fmt.Println("You are not logged in")
+			return
+		}
+		fmt.Printf("Error getting person info: %v\n", err)
 		return
 	}
 
-	var persons []struct {
-		Id     uint   `json:"Id"`
-		Name   string `json:"Name"`
-		Role   string `json:"Role"`
-		School struct {
-			Id   uint   `json:"Id"`
-			Name string `json:"Name"`
-		} `json:"School"`
+	var person dto.Person
+	if err := client.Decode(res, &person); err != nil {
+		fmt.Printf("Error unmarshaling person: %v\n", err)
+		return
 	}
 
-	if err := json.Unmarshal(dataBytes, &persons); err != nil {
-		fmt.Printf("Error unmarshaling persons: %v\n", err)
+	printPersonDetails(person)
+}
+
+func handleLogin(client *tcp.Client) {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	fmt.Println("Enter your person ID:")
+	scanner.Scan()
+	personIdStr := strings.TrimSpace(scanner.Text())
+	personId, err := strconv.ParseUint(personIdStr, 10, 32)
+	if err != nil {
+		fmt.Printf("Invalid person ID: %v\n", err)
 		return
 	}
 
-	if len(persons) == 0 {
-		fmt.Println("No persons found.")
+	password, err := readPassword("Password")
+	if err != nil {
+		fmt.Printf("Prompt failed %v\n", err)
 		return
 	}
 
-	printPersonsTable(persons)
+	person, err := client.Login(context.Background(), uint(personId), password)
+	if err != nil {
+		fmt.Printf("Error logging in: %v\n", err)
+		return
+	}
+	fmt.Printf("Logged in as %s (%s)\n", person.Name, person.Role)
 }
 
-func handleWhoAmI(client *tcp.Client) {
+func handleChangePassword(client *tcp.Client) {
+	oldPassword, err := readPassword("Current password")
+	if err != nil {
+		fmt.Printf("Prompt failed %v\n", err)
+		return
+	}
+	newPassword, err := readPassword("New password")
+	if err != nil {
+		fmt.Printf("Prompt failed %v\n", err)
+		return
+	}
+
+	res, err := client.Send(
+		context.Background(),
+		tcp.ChangePassword,
+		dto.ChangePasswordReq{
+			OldPassword: oldPassword,
+			NewPassword: newPassword,
+		},
+	)
+	if err != nil {
+		fmt.Printf("Error changing password: %v\n", err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error changing password: %v\n", err)
+		return
+	}
+	fmt.Println("Password changed")
+}
+
+// handleSetPassword sends set_password or reset_password, they take the
+// same payload.
+func handleSetPassword(client *tcp.Client, reqType tcp.RequestType) {
 	scanner := bufio.NewScanner(os.Stdin)
 
 	fmt.Println("Enter the person ID:")
@@ -438,66 +863,192 @@ func handleWhoAmI(client *tcp.Client) {
 		return
 	}
 
+	password, err := readPassword("New password")
+	if err != nil {
+		fmt.Printf("Prompt failed %v\n", err)
+		return
+	}
+
 	res, err := client.Send(
 		context.Background(),
-		tcp.WhoAmI,
-		dto.WhoAmIReq{
+		reqType,
+		dto.SetPasswordReq{
 			PersonId: uint(personId),
+			Password: password,
 		},
 	)
 	if err != nil {
-		fmt.Printf("Error getting person info: %v\n", err)
+		fmt.Printf("Error setting password: %v\n", err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error setting password: %v\n", err)
+		return
+	}
+	fmt.Printf("Password of person %d set\n", personId)
+}
+
+func readPassword(label string) (string, error) {
+	prompt := promptui.Prompt{
+		Label: label,
+		Mask:  '*',
+	}
+	return prompt.Run()
+}
+
+// readOptionalId returns 0 when the user enters nothing.
+func readOptionalId(scanner *bufio.Scanner) (uint, error) {
+	scanner.Scan()
+	idStr := strings.TrimSpace(scanner.Text())
+	if idStr == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+func handleLogout(client *tcp.Client) {
+	if err := client.Logout(context.Background()); err != nil {
+		fmt.Printf("Error logging out: %v\n", err)
 		return
 	}
+	fmt.Println("Logged out")
+}
 
-	dataBytes, err := json.Marshal(res.Data)
+func handlePurge(client *tcp.Client) {
+	res, err := client.Send(context.Background(), tcp.Purge, "")
 	if err != nil {
-		fmt.Printf("Error parsing person data: %v\n", err)
+		fmt.Printf("Error purging deleted records: %v\n", err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error purging deleted records: %v\n", err)
 		return
 	}
 
-	var person struct {
-		Id     uint   `json:"Id"`
-		Name   string `json:"Name"`
-		Role   string `json:"Role"`
-		School struct {
-			Id   uint   `json:"Id"`
-			Name string `json:"Name"`
-		} `json:"School"`
+	var purged dto.PurgeRes
+	if err := client.Decode(res, &purged); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
 	}
+	fmt.Printf("Purged %d school(s), %d person(s) and %d class(es)\n",
+		purged.Schools, purged.Persons, purged.Classes)
+}
 
-	if err := json.Unmarshal(dataBytes, &person); err != nil {
-		fmt.Printf("Error unmarshaling person: %v\n", err)
+func handleSearch(client *tcp.Client) {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	fmt.Println("Enter the start of the names to search for:")
+	scanner.Scan()
+	query := strings.TrimSpace(scanner.Text())
+	if query == "" {
+		fmt.Println("Search query cannot be empty")
 		return
 	}
 
-	printPersonDetails(person)
+	res, err := client.Send(context.Background(), tcp.Search, dto.SearchReq{Query: query})
+	if err != nil {
+		fmt.Printf("Error searching: %v\n", err)
+		return
+	}
+	if err := tcp.ResponseError(res); err != nil {
+		fmt.Printf("Error searching: %v\n", err)
+		return
+	}
+
+	var results []dto.SearchResult
+	if err := client.Decode(res, &results); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	if len(results) == 0 {
+		fmt.Println("Nothing found.")
+		return
+	}
+
+	printSearchResultsTable(results)
+}
+
+func handleWatchChanges(client *tcp.Client) {
+	events, err := client.Subscribe(context.Background())
+	if err != nil {
+		fmt.Printf("Error subscribing to changes: %v\n", err)
+		return
+	}
+
+	fmt.Println("Watching changes, press Enter to stop...")
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case e, ok := <-events:
+				if !ok {
+					fmt.Println("Connection closed")
+					return
+				}
+				printEvent(e)
+			}
+		}
+	}()
+
+	bufio.NewScanner(os.Stdin).Scan()
+	close(done)
+
+	if err := client.Unsubscribe(context.Background()); err != nil {
+		fmt.Printf("Error unsubscribing from changes: %v\n", err)
+	}
 }
 
 func mapToClientCfg(cfg *config.ClientConfig) tcp.ClientConfig {
 	return tcp.ClientConfig{
-		Network:         cfg.Network,
-		Address:         cfg.Address,
-		ConnectTimeout:  cfg.Timeouts.Connect,
-		ReadTimeout:     cfg.Timeouts.Read,
-		WriteTimeout:    cfg.Timeouts.Write,
-		KeepAlivePeriod: cfg.Timeouts.KeepAlivePeriod,
-		KeepAlive:       cfg.Limits.KeepAlive,
-		MaxRetries:      cfg.Limits.MaxRetries,
-		RetryDelay:      cfg.Limits.RetryDelay,
-	}
-}
-
-func printSchoolsTable(schools []struct {
-	Id   uint   `json:"Id"`
-	Name string `json:"Name"`
-}) {
+		Network:           cfg.Network,
+		Address:           cfg.Address,
+		Framing:           tcp.Framing(cfg.Framing),
+		Codec:             cfg.Codec,
+		Compression:       cfg.Compression,
+		ConnectTimeout:    cfg.Timeouts.Connect,
+		ReadTimeout:       cfg.Timeouts.Read,
+		WriteTimeout:      cfg.Timeouts.Write,
+		KeepAlivePeriod:   cfg.Timeouts.KeepAlivePeriod,
+		HeartbeatInterval: cfg.Heartbeat.Interval,
+		HeartbeatMisses:   cfg.Heartbeat.Misses,
+		KeepAlive:         cfg.Limits.KeepAlive,
+		MaxRetries:        cfg.Limits.MaxRetries,
+		RetryDelay:        cfg.Limits.RetryDelay,
+		MaxMessageSize:    cfg.Limits.MaxMessageSize,
+		TLS:               mapToTLSCfg(&cfg.TLS),
+	}
+}
+
+func mapToTLSCfg(cfg *config.TLSConfig) *tcp.TLSConfig {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &tcp.TLSConfig{
+		CertFile:   cfg.CertFile,
+		KeyFile:    cfg.KeyFile,
+		CAFile:     cfg.CAFile,
+		ServerName: cfg.ServerName,
+	}
+}
+
+func printSchoolsTable(schools []dto.School) {
 	fmt.Println("\n┌─────┬────────────────────────────────────────┐")
 	fmt.Printf("│ %-3s │ %-38s │\n", "ID", "Name")
 	fmt.Println("├─────┼────────────────────────────────────────┤")
 
 	for _, school := range schools {
 		name := school.Name
+		if school.DeletedAt != nil {
+			name += " (deleted)"
+		}
 		if len(name) > 38 {
 			name = name[:35] + "..."
 		}
@@ -508,17 +1059,33 @@ func printSchoolsTable(schools []struct {
 	fmt.Printf("\nTotal: %d school(s)\n\n", len(schools))
 }
 
-func printClassesTable(classes []struct {
-	Id       uint   `json:"Id"`
-	Name     string `json:"Name"`
-	SchoolId uint   `json:"SchoolId"`
-}) {
+func printSearchResultsTable(results []dto.SearchResult) {
+	fmt.Println("\n┌────────┬─────┬────────────────────────────────────────┐")
+	fmt.Printf("│ %-6s │ %-3s │ %-38s │\n", "Kind", "ID", "Name")
+	fmt.Println("├────────┼─────┼────────────────────────────────────────┤")
+
+	for _, r := range results {
+		name := r.Name
+		if len(name) > 38 {
+			name = name[:35] + "..."
+		}
+		fmt.Printf("│ %-6s │ %-3d │ %-38s │\n", r.Kind, r.Id, name)
+	}
+
+	fmt.Println("└────────┴─────┴────────────────────────────────────────┘")
+	fmt.Printf("\nTotal: %d result(s)\n\n", len(results))
+}
+
+func printClassesTable(classes []dto.Class) {
 	fmt.Println("\n┌─────┬────────────────────────────────────────┬──────────┐")
 	fmt.Printf("│ %-3s │ %-38s │ %-8s │\n", "ID", "Name", "SchoolID")
 	fmt.Println("├─────┼────────────────────────────────────────┼──────────┤")
 
 	for _, class := range classes {
 		name := class.Name
+		if class.DeletedAt != nil {
+			name += " (deleted)"
+		}
 		if len(name) > 38 {
 			name = name[:35] + "..."
 		}
@@ -529,25 +1096,20 @@ func printClassesTable(classes []struct {
 	fmt.Printf("\nTotal: %d class(es)\n\n", len(classes))
 }
 
-func printPersonsTable(persons []struct {
-	Id     uint   `json:"Id"`
-	Name   string `json:"Name"`
-	Role   string `json:"Role"`
-	School struct {
-		Id   uint   `json:"Id"`
-		Name string `json:"Name"`
-	} `json:"School"`
-}) {
+func printPersonsTable(persons []dto.Person) {
 	fmt.Println("\n┌─────┬────────────────────────────────────────┬──────────┬────────────────────────────────────────┐")
 	fmt.Printf("│ %-3s │ %-38s │ %-8s │ %-38s │\n", "ID", "Name", "Role", "School")
 	fmt.Println("├─────┼────────────────────────────────────────┼──────────┼────────────────────────────────────────┤")
 
 	for _, person := range persons {
 		name := person.Name
+		if person.DeletedAt != nil {
+			name += " (deleted)"
+		}
 		if len(name) > 38 {
 			name = name[:35] + "..."
 		}
-		schoolName := person.School.Name
+		schoolName := schoolNameOf(person)
 		if len(schoolName) > 38 {
 			schoolName = schoolName[:35] + "..."
 		}
@@ -558,24 +1120,57 @@ func printPersonsTable(persons []struct {
 	fmt.Printf("\nTotal: %d person(s)\n\n", len(persons))
 }
 
-func printPersonDetails(person struct {
-	Id     uint   `json:"Id"`
-	Name   string `json:"Name"`
-	Role   string `json:"Role"`
-	School struct {
-		Id   uint   `json:"Id"`
-		Name string `json:"Name"`
-	} `json:"School"`
-}) {
+func printPersonDetails(person dto.Person) {
 	fmt.Println("\n┌──────────────────────────────────────────────────────────┐")
 	fmt.Printf("│ ID:     %-50d │\n", person.Id)
 	fmt.Printf("│ Name:   %-50s │\n", person.Name)
 	fmt.Printf("│ Role:   %-50s │\n", person.Role)
-	fmt.Printf("│ School: %-50s │\n", person.School.Name)
+	fmt.Printf("│ School: %-50s │\n", schoolNameOf(person))
 	fmt.Println("└──────────────────────────────────────────────────────────┘")
 	fmt.Println()
 }
 
+func schoolNameOf(person dto.Person) string {
+	if person.School == nil {
+		return ""
+	}
+	return person.School.Name
+}
+
+func printEvent(e dto.Event) {
+	at := e.OccurredAt.Format(time.TimeOnly)
+	switch e.Type {
+	case "school_created":
+		fmt.Printf("[%s] School %d created\n", at, e.SchoolId)
+	case "person_created":
+		fmt.Printf("[%s] Person %d created in school %d\n", at, e.PersonId, e.SchoolId)
+	case "class_created":
+		fmt.Printf("[%s] Class %d created in school %d, teacher %d\n", at, e.ClassId, e.SchoolId, e.PersonId)
+	case "student_added_to_class":
+		fmt.Printf("[%s] Student %d added to class %d\n", at, e.PersonId, e.ClassId)
+	case "school_updated":
+		fmt.Printf("[%s] School %d updated\n", at, e.SchoolId)
+	case "school_deleted":
+		fmt.Printf("[%s] School %d deleted\n", at, e.SchoolId)
+	case "person_updated":
+		fmt.Printf("[%s] Person %d updated\n", at, e.PersonId)
+	case "person_deleted":
+		fmt.Printf("[%s] Person %d deleted\n", at, e.PersonId)
+	case "class_updated":
+		fmt.Printf("[%s] Class %d updated, teacher %d\n", at, e.ClassId, e.PersonId)
+	case "class_deleted":
+		fmt.Printf("[%s] Class %d deleted\n", at, e.ClassId)
+	case "school_restored":
+		fmt.Printf("[%s] School %d restored\n", at, e.SchoolId)
+	case "person_restored":
+		fmt.Printf("[%s] Person %d restored\n", at, e.PersonId)
+	case "class_restored":
+		fmt.Printf("[%s] Class %d restored\n", at, e.ClassId)
+	default:
+		fmt.Printf("[%s] %s\n", at, e.Type)
+	}
+}
+
 func printBanner() {
 	fmt.Println(`
 	_____ _ _            _   
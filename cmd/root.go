@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/arashalaei/go-clean-socket-architecture/cmd/admin"
 	"github.com/arashalaei/go-clean-socket-architecture/cmd/client"
 	"github.com/arashalaei/go-clean-socket-architecture/cmd/server"
 	"github.com/spf13/cobra"
@@ -19,6 +20,7 @@ func Execute() {
 	// Register cmds
 	server.Register(rootCmd)
 	client.Register(rootCmd)
+	admin.Register(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprint(os.Stderr, err)
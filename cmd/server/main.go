@@ -6,13 +6,18 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
 	store "github.com/arashalaei/go-clean-socket-architecture/internal/repository/sqlite"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/class"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/person"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/purge"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/school"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/search"
 	"github.com/arashalaei/go-clean-socket-architecture/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -25,29 +30,58 @@ func main(cfg *config.Config) {
 	if err != nil {
 		log.Fatal(err)
 	}
+	events := event.NewBus()
+	lockout := person.Lockout{
+		MaxAttempts: cfg.Server.Auth.Lockout.Attempts,
+		Duration:    cfg.Server.Auth.Lockout.Duration,
+	}
+
 	schoolUsecases := school.NewSchoolUseCases(
-		school.NewCreateSchoolUseCase(db),
+		school.NewCreateSchoolUseCase(db, events),
 		school.NewListSchoolsUseCase(db),
+		school.NewUpdateSchoolUseCase(db, events),
+		school.NewDeleteSchoolUseCase(db, events),
+		school.NewRestoreSchoolUseCase(db, events),
 	)
 
 	classUsecases := class.NewClassUseCases(
-		class.NewCreateClassUseCase(db),
+		class.NewCreateClassUseCase(db, db, events),
 		class.NewListClassesUseCase(db),
-		class.NewAddStudentToClassUseCase(db),
+		class.NewAddStudentToClassUseCase(db, db, events),
+		class.NewUpdateClassUseCase(db, db, events),
+		class.NewDeleteClassUseCase(db, events),
+		class.NewRestoreClassUseCase(db, db, db, events),
 	)
 
 	personUsecases := person.NewPersonUseCases(
-		person.NewCreatePersonUseCase(db),
+		person.NewCreatePersonUseCase(db, events),
 		person.NewListPersonsUseCase(db),
 		person.NewWhoAmIUseCase(db),
-		person.NewEnrollInSchoolStudentUseCase(db, db),
+		person.NewEnrollInSchoolStudentUseCase(db, db, events),
+		person.NewLoginUseCase(db, lockout),
+		person.NewSetPasswordUseCase(db),
+		person.NewChangePasswordUseCase(db, lockout),
+		person.NewResetPasswordUseCase(db),
+		person.NewUpdatePersonUseCase(db, db, events),
+		person.NewDeletePersonUseCase(db, events),
+		person.NewRestorePersonUseCase(db, events),
 	)
 
+	purgeUseCase := purge.NewPurgeUseCase(db, db, db, db, cfg.Database.PurgeAfter)
+	searchUseCase := search.NewSearchUseCase(db)
+
+	logger := log.New(os.Stdout, "[TCP Server]", log.LstdFlags)
+
 	server := tcp.NewServer(
 		tcp.WithCfg(mapToSrvCfg(&cfg.Server)),
+		tcp.WithLogger(logger),
 		tcp.WithSchoolUsecases(*schoolUsecases),
 		tcp.WithClassUsecases(*classUsecases),
 		tcp.WithPersonUsecases(*personUsecases),
+		tcp.WithEvents(events),
+		tcp.WithTransactor(db),
+		tcp.WithPurgeUseCase(purgeUseCase),
+		tcp.WithSearchUseCase(searchUseCase),
 	)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -61,6 +95,8 @@ func main(cfg *config.Config) {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+	server.Use(tcp.AccessLog(logger))
+
 	server.RegisterHandler(tcp.CreateSchool, server.CreateSchoolHandler)
 	server.RegisterHandler(tcp.ListSchools, server.ListSchoolsHandler)
 	server.RegisterHandler(tcp.CreatePerson, server.CreatePersonHandler)
@@ -69,6 +105,25 @@ func main(cfg *config.Config) {
 	server.RegisterHandler(tcp.ListClasses, server.ListClassesHandler)
 	server.RegisterHandler(tcp.AddStudentToClass, server.AddStudentToClassHandler)
 	server.RegisterHandler(tcp.WhoAmI, server.WhoAmIHandler)
+	server.RegisterHandler(tcp.Login, server.LoginHandler)
+	server.RegisterHandler(tcp.Logout, server.LogoutHandler)
+	server.RegisterHandler(tcp.SetPassword, server.SetPasswordHandler)
+	server.RegisterHandler(tcp.ChangePassword, server.ChangePasswordHandler)
+	server.RegisterHandler(tcp.ResetPassword, server.ResetPasswordHandler)
+	server.RegisterHandler(tcp.UpdateSchool, server.UpdateSchoolHandler)
+	server.RegisterHandler(tcp.DeleteSchool, server.DeleteSchoolHandler)
+	server.RegisterHandler(tcp.UpdatePerson, server.UpdatePersonHandler)
+	server.RegisterHandler(tcp.DeletePerson, server.DeletePersonHandler)
+	server.RegisterHandler(tcp.UpdateClass, server.UpdateClassHandler)
+	server.RegisterHandler(tcp.DeleteClass, server.DeleteClassHandler)
+	server.RegisterHandler(tcp.RestoreSchool, server.RestoreSchoolHandler)
+	server.RegisterHandler(tcp.RestorePerson, server.RestorePersonHandler)
+	server.RegisterHandler(tcp.RestoreClass, server.RestoreClassHandler)
+	server.RegisterHandler(tcp.Purge, server.PurgeHandler)
+	server.RegisterHandler(tcp.Search, server.SearchHandler)
+	server.RegisterStreamHandler(tcp.StreamSchools, server.StreamSchoolsHandler)
+	server.RegisterStreamHandler(tcp.StreamPersons, server.StreamPersonsHandler)
+	server.RegisterStreamHandler(tcp.StreamClasses, server.StreamClassesHandler)
 
 	<-stop
 	log.Println("Shutdown signal received")
@@ -81,14 +136,108 @@ func main(cfg *config.Config) {
 
 func mapToSrvCfg(cfg *config.ServerConfig) tcp.SrvCfg {
 	return tcp.SrvCfg{
-		Network:         cfg.Network,
-		Address:         cfg.Address,
-		ReadTimeout:     cfg.Timeouts.Read,
-		WriteTimeout:    cfg.Timeouts.Write,
-		IdleTimeout:     cfg.Timeouts.Idle,
-		ShutdownTimeout: cfg.Timeouts.Shutdown,
-		MaxConnections:  cfg.Limits.MaxConnectionsSize,
-		MaxMessageSize:  cfg.Limits.MaxMessageSize,
+		Network:           cfg.Network,
+		Address:           cfg.Address,
+		Framing:           tcp.Framing(cfg.Framing),
+		ReadTimeout:       cfg.Timeouts.Read,
+		WriteTimeout:      cfg.Timeouts.Write,
+		IdleTimeout:       cfg.Timeouts.Idle,
+		ShutdownTimeout:   cfg.Timeouts.Shutdown,
+		MaxConnections:    cfg.Limits.MaxConnectionsSize,
+		MaxMessageSize:    cfg.Limits.MaxMessageSize,
+		MaxInFlight:       cfg.Limits.MaxInFlight,
+		HeartbeatInterval: cfg.Heartbeat.Interval,
+		HeartbeatMisses:   cfg.Heartbeat.Misses,
+		RateLimit:         mapToRateLimitCfg(&cfg.RateLimit),
+		Auth:              mapToAuthCfg(&cfg.Auth),
+		TLS:               mapToTLSCfg(&cfg.TLS),
+		Unix:              mapToUnixCfg(&cfg.Unix),
+		Listeners:         mapToListenerCfgs(cfg.Listeners),
+	}
+}
+
+func mapToAuthCfg(cfg *config.AuthConfig) tcp.AuthCfg {
+	var allow []tcp.RequestType
+	for _, t := range cfg.Allow {
+		allow = append(allow, tcp.RequestType(t))
+	}
+
+	policy := make(tcp.Policy)
+	for t, roles := range cfg.Policy {
+		for _, role := range roles {
+			policy[tcp.RequestType(t)] = append(policy[tcp.RequestType(t)], entity.Role(role))
+		}
+	}
+
+	return tcp.AuthCfg{
+		Required: cfg.Required,
+		Allow:    allow,
+		Policy:   policy,
+	}
+}
+
+func mapToRateLimitCfg(cfg *config.RateLimitConfig) tcp.RateLimitCfg {
+	types := make(map[tcp.RequestType]tcp.RateLimits)
+	for t, limits := range cfg.Types {
+		types[tcp.RequestType(t)] = mapToRateLimits(&limits)
+	}
+
+	return tcp.RateLimitCfg{
+		RateLimits: mapToRateLimits(&cfg.RateLimitsConfig),
+		Types:      types,
+	}
+}
+
+func mapToRateLimits(cfg *config.RateLimitsConfig) tcp.RateLimits {
+	return tcp.RateLimits{
+		PerConnection: tcp.RateLimit{Rate: cfg.Connection.Rate, Burst: cfg.Connection.Burst},
+		PerIP:         tcp.RateLimit{Rate: cfg.IP.Rate, Burst: cfg.IP.Burst},
+		PerIdentity:   tcp.RateLimit{Rate: cfg.Identity.Rate, Burst: cfg.Identity.Burst},
+	}
+}
+
+func mapToListenerCfgs(cfgs []config.ListenerConfig) []tcp.ListenerCfg {
+	var listeners []tcp.ListenerCfg
+	for _, cfg := range cfgs {
+		listeners = append(listeners, tcp.ListenerCfg{
+			Network:      cfg.Network,
+			Address:      cfg.Address,
+			TLS:          mapToTLSCfg(&cfg.TLS),
+			Unix:         mapToUnixCfg(&cfg.Unix),
+			ReadTimeout:  cfg.Timeouts.Read,
+			WriteTimeout: cfg.Timeouts.Write,
+			IdleTimeout:  cfg.Timeouts.Idle,
+		})
+	}
+	return listeners
+}
+
+func mapToUnixCfg(cfg *config.UnixSocketConfig) tcp.UnixSocketConfig {
+	var mode os.FileMode
+	if cfg.Mode != "" {
+		m, err := strconv.ParseUint(cfg.Mode, 8, 32)
+		if err != nil {
+			log.Fatalf("invalid unix socket mode %q: %v", cfg.Mode, err)
+		}
+		mode = os.FileMode(m)
+	}
+
+	return tcp.UnixSocketConfig{
+		Mode:  mode,
+		User:  cfg.User,
+		Group: cfg.Group,
+	}
+}
+
+func mapToTLSCfg(cfg *config.TLSConfig) *tcp.TLSConfig {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &tcp.TLSConfig{
+		CertFile:          cfg.CertFile,
+		KeyFile:           cfg.KeyFile,
+		CAFile:            cfg.CAFile,
+		RequireClientCert: cfg.RequireClientCert,
 	}
 }
 
@@ -0,0 +1,97 @@
+package tcp
+
+import (
+	"context"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/mapper"
+)
+
+// LoginHandler binds the person to the connection, replacing whoever was
+// logged in before. A failed login leaves the session as it was.
+func (s *server) LoginHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.LoginReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	personUsecases := s.personUsecases
+	person, err := personUsecases.LoginUseCase.Execute(ctx, req.PersonId, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionFromContext(ctx).set(&Identity{
+		PersonId: person.Id,
+		Name:     person.Name,
+		Role:     person.Role,
+	})
+	if info, ok := RequestInfoFromContext(ctx); ok {
+		s.logger.Printf("%s logged in as person %d\n", info.RemoteAddr, person.Id)
+	}
+
+	return mapper.PersonToDto(person), nil
+}
+
+func (s *server) LogoutHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	sessionFromContext(ctx).set(nil)
+	return "logged out successfully", nil
+}
+
+func (s *server) SetPasswordHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.SetPasswordReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	personUsecases := s.personUsecases
+	err = personUsecases.SetPasswordUseCase.Execute(ctx, req.PersonId, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return "password set successfully", nil
+}
+
+func (s *server) ChangePasswordHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.ChangePasswordReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, ok := SessionIdentity(ctx)
+	if !ok {
+		return nil, NewError(CodeUnauthenticated, "not logged in")
+	}
+
+	personUsecases := s.personUsecases
+	err = personUsecases.ChangePasswordUseCase.Execute(ctx, identity.PersonId, req.OldPassword, req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return "password changed successfully", nil
+}
+
+func (s *server) ResetPasswordHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.SetPasswordReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	personUsecases := s.personUsecases
+	err = personUsecases.ResetPasswordUseCase.Execute(ctx, req.PersonId, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return "password reset successfully", nil
+}
@@ -0,0 +1,99 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+)
+
+var errRollback = errors.New("rollback")
+
+// unbatchable are the request types that can't be sent in a batch. The
+// auth types change the session or count failed logins, which a rolled
+// back batch would undo, so a batch of wrong passwords never locked out.
+var unbatchable = map[RequestType]bool{
+	Login:          true,
+	Logout:         true,
+	SetPassword:    true,
+	ChangePassword: true,
+	ResetPassword:  true,
+}
+
+// batch runs the requests of a batch one after another and replies with
+// their responses in the same order. An atomic batch runs in a single
+// transaction and stops at the first failure, undoing the requests before
+// it. Its events are only published once it commits.
+func (s *server) batch(ctx context.Context, c *connection, req dto.Request) dto.Response {
+	var body dto.BatchReq
+	if err := c.codec.Unmarshal(req.Payload, &body); err != nil {
+		return errorResponse(req.Id, NewError(CodeInvalidArgument, "invalid payload"))
+	}
+
+	if len(body.Requests) == 0 {
+		return errorResponse(req.Id, NewError(CodeInvalidArgument, "batch is empty"))
+	}
+	for _, item := range body.Requests {
+		itemType, _ := resolveRequestType(RequestType(item.Type), c.version)
+		switch {
+		case itemType == Batch:
+			return errorResponse(req.Id, NewError(CodeInvalidArgument, "batches can't be nested"))
+		case unbatchable[itemType]:
+			return errorResponse(req.Id, NewError(CodeInvalidArgument,
+				fmt.Sprintf("%s can't be sent in a batch", itemType)))
+		}
+	}
+
+	results := make([]dto.Response, len(body.Requests))
+	if !body.Atomic {
+		for i, item := range body.Requests {
+			results[i] = s.execute(ctx, c, item)
+		}
+	} else {
+		if s.transactor == nil {
+			return errorResponse(req.Id, NewError(CodeUnimplemented, "atomic batches are not enabled"))
+		}
+
+		ctx, release := event.Defer(ctx)
+		failed := -1
+		err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			for i, item := range body.Requests {
+				results[i] = s.execute(ctx, c, item)
+				if !results[i].Status {
+					failed = i
+					return errRollback
+				}
+			}
+			return nil
+		})
+
+		switch {
+		case failed >= 0:
+			for i, item := range body.Requests {
+				if i < failed {
+					results[i] = errorResponse(item.Id, NewError(CodeAborted, "rolled back"))
+				} else if i > failed {
+					results[i] = errorResponse(item.Id, NewError(CodeAborted, "not executed"))
+				}
+			}
+		case err != nil:
+			s.logger.Printf("Failed to commit batch %s: %v\n", req.Id, err)
+			return errorResponse(req.Id, toError(err))
+		default:
+			release()
+		}
+	}
+
+	data, err := c.codec.Marshal(results)
+	if err != nil {
+		return errorResponse(req.Id, NewError(CodeInternal, "failed to encode response"))
+	}
+
+	return dto.Response{
+		Id:     req.Id,
+		Status: true,
+		Data:   data,
+	}
+}
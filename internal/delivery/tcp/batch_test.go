@@ -0,0 +1,64 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/codec"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestBatchRejectsUnbatchable(t *testing.T) {
+	s := NewServer(WithTransactor(fakeTransactor{})).(*server)
+
+	var calls int
+	for reqType := range unbatchable {
+		s.RegisterHandler(reqType, func(ctx context.Context, payload Payload) (interface{}, error) {
+			calls++
+			return nil, NewError(CodeUnauthenticated, "invalid credentials")
+		})
+	}
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		calls++
+		return []dto.School{}, nil
+	})
+
+	types := []RequestType{Batch}
+	for reqType := range unbatchable {
+		types = append(types, reqType)
+	}
+
+	for _, reqType := range types {
+		for _, atomic := range []bool{false, true} {
+			body := dto.BatchReq{
+				Atomic: atomic,
+				Requests: []dto.Request{
+					{Id: "1", Type: string(ListSchools)},
+					{Id: "2", Type: string(reqType), Payload: json.RawMessage(`{}`)},
+				},
+			}
+			payload, err := json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := &connection{codec: codec.Default()}
+			res := s.batch(context.Background(), c, dto.Request{Id: "b", Type: string(Batch), Payload: payload})
+			if res.Status || res.Code != string(CodeInvalidArgument) {
+				t.Errorf("%s in batch (atomic %v): status %v code %q, want %s",
+					reqType, atomic, res.Status, res.Code, CodeInvalidArgument)
+			}
+		}
+	}
+
+	if calls != 0 {
+		t.Fatalf("handlers ran %d times, a rejected batch must not run any item", calls)
+	}
+}
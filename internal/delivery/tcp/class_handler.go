@@ -2,47 +2,155 @@ package tcp
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/mapper"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 )
 
-func (s *server) CreateClassHandler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+func (s *server) CreateClassHandler(ctx context.Context, payload Payload) (interface{}, error) {
 	var req dto.CreateClassReq
 
-	err := json.Unmarshal(payload, &req)
+	err := payload.Decode(&req)
 	if err != nil {
 		return nil, err
 	}
 
 	classUsecases := s.classUsecases
-	classId := classUsecases.CreateUseCase.Execute(req.Name, req.SchoolId, req.TeacherId)
+	classId, err := classUsecases.CreateUseCase.Execute(ctx, req.Name, req.SchoolId, req.TeacherId)
+	if err != nil {
+		return nil, err
+	}
 
 	return classId, nil
 }
 
-func (s *server) ListClassesHandler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+func (s *server) ListClassesHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.ListClassesReq
+
+	err := payload.decodeOptional(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts, err := listOptions(dto.ListReq{
+		IncludeDeleted: req.IncludeDeleted,
+		PageSize:       req.PageSize,
+		Cursor:         req.Cursor,
+		Sort:           req.Sort,
+		NamePrefix:     req.NamePrefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	opts := repository.ClassListOptions{
+		ListOptions: listOpts,
+		SchoolId:    req.SchoolId,
+		TeacherId:   req.TeacherId,
+	}
+
 	classUsecases := s.classUsecases
-	classes, err := classUsecases.ListUseCase.Execute()
+	classes, next, err := classUsecases.ListUseCase.Execute(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
-	return classes, nil
+	if req.PageSize == 0 {
+		return mapper.ClassesToDtos(classes), nil
+	}
+	return dto.ClassPage{Items: mapper.ClassesToDtos(classes), NextCursor: next}, nil
+}
+
+func (s *server) StreamClassesHandler(ctx context.Context, payload Payload, send func(chunk interface{}) error) error {
+	var req dto.StreamClassesReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	streamReq := dto.StreamReq{
+		ChunkSize:      req.ChunkSize,
+		IncludeDeleted: req.IncludeDeleted,
+		Sort:           req.Sort,
+		NamePrefix:     req.NamePrefix,
+	}
+	opts := repository.ClassListOptions{
+		ListOptions: streamOptions(streamReq),
+		SchoolId:    req.SchoolId,
+		TeacherId:   req.TeacherId,
+	}
+
+	classUsecases := s.classUsecases
+	return classUsecases.ListUseCase.ExecuteInBatches(ctx, opts, chunkSize(streamReq), func(classes *[]entity.Class) error {
+		return send(mapper.ClassesToDtos(classes))
+	})
 }
 
-func (s *server) AddStudentToClassHandler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+func (s *server) AddStudentToClassHandler(ctx context.Context, payload Payload) (interface{}, error) {
 	var req dto.AddStudentToClassReq
 
-	err := json.Unmarshal(payload, &req)
+	err := payload.Decode(&req)
 	if err != nil {
 		return nil, err
 	}
 
 	classUsecases := s.classUsecases
-	err = classUsecases.AddStudentToClassUseCase.Execute(req.ClassId, req.StudentId)
+	err = classUsecases.AddStudentToClassUseCase.Execute(ctx, req.ClassId, req.StudentId)
 	if err != nil {
 		return nil, err
 	}
 
 	return "student added to class successfully", nil
 }
+
+func (s *server) UpdateClassHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.UpdateClassReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	classUsecases := s.classUsecases
+	class, err := classUsecases.UpdateUseCase.Execute(ctx, req.Id, req.Name, req.TeacherId)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.ClassToDto(class), nil
+}
+
+func (s *server) DeleteClassHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.IdReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	classUsecases := s.classUsecases
+	err = classUsecases.DeleteUseCase.Execute(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return "class deleted successfully", nil
+}
+
+func (s *server) RestoreClassHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.IdReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	classUsecases := s.classUsecases
+	err = classUsecases.RestoreUseCase.Execute(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return "class restored successfully", nil
+}
@@ -3,18 +3,26 @@ package tcp
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/codec"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
 )
 
 type ClientConfig struct {
-	Network         string
-	Address         string
+	Network string
+	Address string
+	Framing Framing
+	Codec   string
+	// Compression is negotiated with hello, it needs length framing
+	Compression     string
 	ConnectTimeout  time.Duration
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
@@ -22,15 +30,48 @@ type ClientConfig struct {
 	RetryDelay      time.Duration
 	KeepAlive       bool
 	KeepAlivePeriod time.Duration
+	// HeartbeatInterval enables pings to the server, the connection is
+	// closed after HeartbeatMisses unanswered ones
+	HeartbeatInterval time.Duration
+	HeartbeatMisses   int
+	// TLS is optional, a nil config dials plain TCP
+	TLS *TLSConfig
+	// MaxMessageSize closes the connection when the server sends a
+	// bigger frame, zero means defaultClientMaxMessageSize
+	MaxMessageSize int64
 }
 
+// replies can be a lot bigger than requests, a list without a page size
+// holds every record
+const defaultClientMaxMessageSize = 64 * 1024 * 1024
+
 type Client struct {
-	config *ClientConfig
-	conn   net.Conn
-	mu     sync.RWMutex
-	reader *bufio.Reader
-	writer *bufio.Writer
-	closed bool
+	config  *ClientConfig
+	conn    net.Conn
+	mu      sync.RWMutex
+	writeMu sync.Mutex
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	closed  bool
+	codec   codec.Codec
+
+	// agreed on with hello, version 1 without handshake
+	compression  Compression
+	version      int
+	requestTypes map[RequestType]bool
+
+	// replies are matched to their request by id, so many requests can
+	// be in flight on the same connection
+	nextId    atomic.Uint64
+	pending   map[string]*pendingCall
+	pendingMu sync.Mutex
+
+	// event frames, nil until Subscribe is called
+	events chan dto.Event
+
+	// reset for every connection
+	heartbeat *heartbeat
+	done      chan struct{}
 }
 
 type clientOps func(*Client)
@@ -46,6 +87,8 @@ func NewClient(ops ...clientOps) *Client {
 		config: &ClientConfig{
 			Network:         "tcp",
 			Address:         "localhost:8080",
+			Framing:         NewlineFraming,
+			Codec:           codec.JSON,
 			ConnectTimeout:  10 * time.Second,
 			ReadTimeout:     30 * time.Second,
 			WriteTimeout:    30 * time.Second,
@@ -54,6 +97,7 @@ func NewClient(ops ...clientOps) *Client {
 			KeepAlive:       true,
 			KeepAlivePeriod: 30 * time.Second,
 		},
+		pending: make(map[string]*pendingCall),
 	}
 
 	for _, o := range ops {
@@ -63,7 +107,113 @@ func NewClient(ops ...clientOps) *Client {
 	return c
 }
 
+type pendingCall struct {
+	ch chan *dto.Response
+	// runs on the read loop before the next frame is read, for replies
+	// that change how the following frames are encoded
+	onReply func(*dto.Response)
+	// streams get every chunk frame and the end frame on ch, done is
+	// closed once nobody reads them anymore
+	stream bool
+	done   chan struct{}
+}
+
 func (c *Client) Connect() error {
+	if err := c.dial(); err != nil {
+		return err
+	}
+
+	if err := c.handshake(); err != nil {
+		c.Close()
+		return err
+	}
+
+	c.mu.RLock()
+	conn, hb, done := c.conn, c.heartbeat, c.done
+	c.mu.RUnlock()
+	if c.config.HeartbeatInterval > 0 {
+		go c.sendHeartbeats(conn, hb, done)
+	}
+
+	return nil
+}
+
+// handshake sends hello and applies what the server agreed to. Servers
+// that predate hello are spoken to with protocol version 1.
+func (c *Client) handshake() error {
+	req := dto.HelloReq{Version: ProtocolVersion}
+	if c.config.Codec != "" {
+		req.Codecs = []string{c.config.Codec}
+	}
+	if c.config.Compression != "" {
+		req.Compressions = []string{c.config.Compression}
+	}
+
+	var agreed dto.HelloRes
+	res, err := c.send(context.Background(), Hello, req, 0, func(res *dto.Response) {
+		if !res.Status || c.Decode(res, &agreed) != nil {
+			return
+		}
+
+		cd, err := codec.Get(agreed.Codec)
+		if err != nil {
+			return
+		}
+
+		c.mu.Lock()
+		c.codec = cd
+		c.compression = Compression(agreed.Compression)
+		c.version = agreed.Version
+		c.requestTypes = make(map[RequestType]bool)
+		for _, t := range agreed.RequestTypes {
+			c.requestTypes[RequestType(t)] = true
+		}
+		c.mu.Unlock()
+	})
+	if err != nil {
+		return fmt.Errorf("handshake failed: %w", err)
+	}
+
+	// servers without error codes predate hello too
+	err = ResponseError(res)
+	if HasCode(err, CodeUnimplemented) || (err != nil && res.Code == "") {
+		if c.config.Compression != "" && Compression(c.config.Compression) != NoCompression {
+			return fmt.Errorf("server does not support compression")
+		}
+		if c.config.Codec != "" && c.config.Codec != codec.JSON {
+			return c.negotiateCodec(c.config.Codec)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("handshake failed: %w", err)
+	}
+
+	return nil
+}
+
+// ProtocolVersion returns the version agreed on with the server.
+func (c *Client) ProtocolVersion() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// Supports reports whether the server handles t. It is only known after
+// a hello handshake, servers without one are assumed to handle anything.
+func (c *Client) Supports(t RequestType) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.requestTypes == nil || c.requestTypes[t]
+}
+
+func (c *Client) dial() error {
+	if c.IsConnected() {
+		return fmt.Errorf("already connected")
+	}
+	// drop what is left of a connection the server closed
+	c.Close()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -71,6 +221,10 @@ func (c *Client) Connect() error {
 		return fmt.Errorf("already connected")
 	}
 
+	if err := c.config.Framing.validate(); err != nil {
+		return err
+	}
+
 	dialer := &net.Dialer{
 		Timeout:   c.config.ConnectTimeout,
 		KeepAlive: c.config.KeepAlivePeriod,
@@ -88,43 +242,305 @@ func (c *Client) Connect() error {
 		}
 	}
 
+	if c.config.TLS != nil {
+		tlsCfg, err := c.config.TLS.clientConfig(c.config.Address)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+
+		tlsConn := tls.Client(conn, tlsCfg)
+		tlsConn.SetDeadline(time.Now().Add(c.config.ConnectTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		tlsConn.SetDeadline(time.Time{})
+		conn = tlsConn
+	}
+
 	c.conn = conn
 	c.reader = bufio.NewReader(conn)
 	c.writer = bufio.NewWriter(conn)
 	c.closed = false
+	c.codec = codec.Default()
+	c.compression = NoCompression
+	c.version = MinProtocolVersion
+	c.requestTypes = nil
+	c.heartbeat = &heartbeat{}
+	c.done = make(chan struct{})
+
+	go c.readLoop(conn, newFrameReader(c.config.Framing, c.reader, c.maxMessageSize()), c.done)
 
 	return nil
 }
 
+func (c *Client) maxMessageSize() int64 {
+	if c.config.MaxMessageSize > 0 {
+		return c.config.MaxMessageSize
+	}
+	return defaultClientMaxMessageSize
+}
+
+// negotiateCodec asks the server to switch the connection to the named
+// codec. It has to happen before any other request is sent.
+func (c *Client) negotiateCodec(name string) error {
+	cd, err := codec.Get(name)
+	if err != nil {
+		return err
+	}
+
+	if cd.Binary() && c.config.Framing != LengthPrefixedFraming {
+		return fmt.Errorf("codec %s requires length-prefixed framing", name)
+	}
+
+	res, err := c.send(context.Background(), SetCodec, dto.SetCodecReq{Name: name}, 0,
+		func(res *dto.Response) {
+			if res.Status {
+				c.mu.Lock()
+				c.codec = cd
+				c.mu.Unlock()
+			}
+		})
+	if err != nil {
+		return fmt.Errorf("failed to negotiate codec: %w", err)
+	}
+	if err := ResponseError(res); err != nil {
+		return fmt.Errorf("failed to negotiate codec: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) currentCodec() codec.Codec {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.codec
+}
+
+func (c *Client) currentCompression() Compression {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.compression
+}
+
+// Decode decodes the data of a response with the codec of the connection.
+func (c *Client) Decode(res *dto.Response, v interface{}) error {
+	return c.currentCodec().Unmarshal(res.Data, v)
+}
+
+func (c *Client) readLoop(conn net.Conn, reader frameReader, done chan struct{}) {
+	defer close(done)
+	defer c.closePending()
+	defer func() {
+		c.mu.Lock()
+		if c.conn == conn {
+			c.closed = true
+		}
+		if c.events != nil {
+			close(c.events)
+			c.events = nil
+		}
+		c.mu.Unlock()
+	}()
+
+	for {
+		respBytes, err := reader.ReadFrame()
+		if err != nil {
+			// the rest of the frame is left unread, nothing after it
+			// can be read either
+			if err == ErrFrameTooLarge {
+				conn.Close()
+			}
+			return
+		}
+
+		respBytes, err = c.currentCompression().decompress(respBytes, c.maxMessageSize())
+		if err != nil {
+			continue
+		}
+
+		var resp dto.Response
+		if err := c.currentCodec().Unmarshal(respBytes, &resp); err != nil {
+			continue
+		}
+
+		if resp.Type == FrameGoAway {
+			// the server closes the connection once it is drained
+			c.mu.Lock()
+			c.closed = true
+			c.mu.Unlock()
+			continue
+		}
+
+		if resp.Type == FramePing {
+			go c.answerPing(resp.Id)
+			continue
+		}
+
+		if resp.Type == FrameEvent {
+			var e dto.Event
+			if err := c.Decode(&resp, &e); err == nil {
+				c.deliverEvent(e)
+			}
+			continue
+		}
+
+		c.pendingMu.Lock()
+		call, ok := c.pending[resp.Id]
+		if ok && !(call.stream && resp.Type == FrameChunk) {
+			delete(c.pending, resp.Id)
+		}
+		c.pendingMu.Unlock()
+
+		if !ok {
+			continue
+		}
+		if call.onReply != nil {
+			call.onReply(&resp)
+		}
+		if call.stream {
+			// a slow reader holds back the frames after this one
+			select {
+			case call.ch <- &resp:
+			case <-call.done:
+			}
+			continue
+		}
+		call.ch <- &resp
+	}
+}
+
+// deliverEvent hands e to the channel returned by Subscribe, dropping it
+// if nobody keeps up with the events.
+func (c *Client) deliverEvent(e dto.Event) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.events == nil {
+		return
+	}
+
+	select {
+	case c.events <- e:
+	default:
+	}
+}
+
+// Subscribe asks the server to push the given event types, every type
+// when none are given. Events arrive on the returned channel, which is
+// closed with the connection. Calling it again replaces the types.
+func (c *Client) Subscribe(ctx context.Context, types ...string) (<-chan dto.Event, error) {
+	c.mu.Lock()
+	if c.conn == nil || c.closed {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("not connected")
+	}
+	if c.events == nil {
+		c.events = make(chan dto.Event, eventBuffer)
+	}
+	events := c.events
+	c.mu.Unlock()
+
+	res, err := c.Send(ctx, Subscribe, dto.SubscribeReq{Events: types})
+	if err != nil {
+		return nil, err
+	}
+	if err := ResponseError(res); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Unsubscribe stops event frames, the channel of Subscribe stays open.
+func (c *Client) Unsubscribe(ctx context.Context) error {
+	res, err := c.Send(ctx, Unsubscribe, "")
+	if err != nil {
+		return err
+	}
+	return ResponseError(res)
+}
+
+// Login authenticates the connection as the person. The session ends
+// with the connection, a new one has to log in again.
+func (c *Client) Login(ctx context.Context, personId uint, password string) (*dto.Person, error) {
+	res, err := c.Send(ctx, Login, dto.LoginReq{PersonId: personId, Password: password})
+	if err != nil {
+		return nil, err
+	}
+	if err := ResponseError(res); err != nil {
+		return nil, err
+	}
+
+	var person dto.Person
+	if err := c.Decode(res, &person); err != nil {
+		return nil, fmt.Errorf("failed to decode person: %w", err)
+	}
+	return &person, nil
+}
+
+func (c *Client) Logout(ctx context.Context) error {
+	res, err := c.Send(ctx, Logout, "")
+	if err != nil {
+		return err
+	}
+	return ResponseError(res)
+}
+
+func (c *Client) closePending() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	for id, call := range c.pending {
+		close(call.ch)
+		delete(c.pending, id)
+	}
+}
+
 func (c *Client) Send(
 	ctx context.Context,
 	requestType RequestType,
 	payload interface{},
+) (*dto.Response, error) {
+	return c.send(ctx, requestType, payload, c.config.MaxRetries, nil)
+}
+
+func (c *Client) send(
+	ctx context.Context,
+	requestType RequestType,
+	payload interface{},
+	maxRetries int,
+	onReply func(*dto.Response),
 ) (*dto.Response, error) {
 	c.mu.RLock()
 	if c.conn == nil || c.closed {
 		c.mu.RUnlock()
 		return nil, fmt.Errorf("not connected")
 	}
+	version := c.version
 	c.mu.RUnlock()
 
-	payloadBytes, err := json.Marshal(payload)
+	cd := c.currentCodec()
+
+	payloadBytes, err := cd.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
 	req := dto.Request{
-		Type:    string(requestType),
+		Id:      strconv.FormatUint(c.nextId.Add(1), 10),
+		Type:    string(legacyRequestType(requestType, version)),
 		Payload: payloadBytes,
 	}
 
-	reqBytes, err := json.Marshal(req)
+	reqBytes, err := cd.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	var resp *dto.Response
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			select {
 			case <-ctx.Done():
@@ -133,7 +549,7 @@ func (c *Client) Send(
 			}
 		}
 
-		resp, err = c.sendWithTimeout(ctx, reqBytes)
+		resp, err = c.sendWithTimeout(ctx, req.Id, reqBytes, onReply)
 		if err == nil {
 			return resp, nil
 		}
@@ -146,59 +562,152 @@ func (c *Client) Send(
 	return nil, err
 }
 
-func (c *Client) sendWithTimeout(ctx context.Context, reqBytes []byte) (*dto.Response, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// BatchItem is one request of a batch.
+type BatchItem struct {
+	Type    RequestType
+	Payload interface{}
+}
+
+// Batch sends items in a single request and returns their responses in
+// the same order. An atomic batch runs in one transaction on the server:
+// if any item fails, none of them has an effect.
+func (c *Client) Batch(ctx context.Context, atomic bool, items ...BatchItem) ([]*dto.Response, error) {
+	cd := c.currentCodec()
+	version := c.ProtocolVersion()
+
+	body := dto.BatchReq{Atomic: atomic}
+	for i, item := range items {
+		payload, err := cd.Marshal(item.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload of item %d: %w", i, err)
+		}
+
+		body.Requests = append(body.Requests, dto.Request{
+			Id:      strconv.Itoa(i),
+			Type:    string(legacyRequestType(item.Type, version)),
+			Payload: payload,
+		})
+	}
+
+	res, err := c.Send(ctx, Batch, body)
+	if err != nil {
+		return nil, err
+	}
+	if err := ResponseError(res); err != nil {
+		return nil, err
+	}
+
+	var results []*dto.Response
+	if err := c.Decode(res, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode batch results: %w", err)
+	}
+	if len(results) != len(items) {
+		return nil, fmt.Errorf("batch returned %d results for %d items", len(results), len(items))
+	}
+
+	return results, nil
+}
+
+func (c *Client) sendWithTimeout(
+	ctx context.Context,
+	id string,
+	reqBytes []byte,
+	onReply func(*dto.Response),
+) (*dto.Response, error) {
+	ch := make(chan *dto.Response, 1)
+
+	c.pendingMu.Lock()
+	c.pending[id] = &pendingCall{ch: ch, onReply: onReply}
+	c.pendingMu.Unlock()
+
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, id)
+		c.pendingMu.Unlock()
+	}()
+
+	if err := c.write(ctx, reqBytes); err != nil {
+		return nil, err
+	}
 
 	deadline, ok := ctx.Deadline()
 	if !ok {
-		deadline = time.Now().Add(c.config.WriteTimeout)
+		deadline = time.Now().Add(c.config.ReadTimeout)
 	}
-	c.conn.SetWriteDeadline(deadline)
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
 
-	reqBytes = append(reqBytes, '\n')
-	if _, err := c.writer.Write(reqBytes); err != nil {
-		if ne, ok := err.(net.Error); ok && ne.Timeout() {
-			return nil, fmt.Errorf("timeout for write")
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, fmt.Errorf("connection closed")
 		}
-		return nil, fmt.Errorf("failed to write request: %w", err)
+		return resp, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("timeout for read")
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
+}
 
-	if err := c.writer.Flush(); err != nil {
-		return nil, fmt.Errorf("failed to flush: %w", err)
+func (c *Client) write(ctx context.Context, reqBytes []byte) error {
+	c.mu.RLock()
+	conn := c.conn
+	compression := c.compression
+	c.mu.RUnlock()
+	if conn == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	reqBytes, err := compression.compress(reqBytes)
+	if err != nil {
+		return fmt.Errorf("failed to compress request: %w", err)
 	}
 
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	deadline, ok := ctx.Deadline()
 	if !ok {
-		deadline = time.Now().Add(c.config.ReadTimeout)
+		deadline = time.Now().Add(c.config.WriteTimeout)
 	}
-	c.conn.SetReadDeadline(deadline)
+	conn.SetWriteDeadline(deadline)
 
-	respBytes, err := c.reader.ReadBytes('\n')
-	if err != nil {
+	frame := encodeFrame(c.config.Framing, reqBytes)
+	if _, err := c.writer.Write(frame); err != nil {
 		if ne, ok := err.(net.Error); ok && ne.Timeout() {
-			return nil, fmt.Errorf("timeout for read")
+			return fmt.Errorf("timeout for write")
 		}
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to write request: %w", err)
 	}
 
-	var resp dto.Response
-	if err := json.Unmarshal(respBytes, &resp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := c.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush: %w", err)
 	}
 
-	return &resp, nil
+	return nil
 }
 
+// Close closes the connection and waits for its read loop to stop, the
+// client can Connect again afterwards. It also releases connections the
+// server already closed.
 func (c *Client) Close() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	conn, done := c.conn, c.done
+	c.conn = nil
+	c.closed = true
+	c.mu.Unlock()
 
-	if c.conn == nil || c.closed {
+	if conn == nil {
 		return nil
 	}
 
-	c.closed = true
-	return c.conn.Close()
+	err := conn.Close()
+	<-done
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
 }
 
 func (c *Client) IsConnected() bool {
@@ -0,0 +1,213 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+// fakeServer answers every request on newline framing with an empty
+// successful response, or a failed one while fail is set.
+type fakeServer struct {
+	ln   net.Listener
+	fail atomic.Bool
+
+	mu    sync.Mutex
+	conns []net.Conn
+	// gets a value when a connection is closed by its client
+	eof chan struct{}
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln, eof: make(chan struct{}, 16)}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			s.eof <- struct{}{}
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		var req dto.Request
+		if json.Unmarshal(line, &req) != nil {
+			continue
+		}
+		res := dto.Response{Id: req.Id, Status: !s.fail.Load()}
+		if !res.Status {
+			res.Code = string(CodeInternal)
+			res.Message = "broken"
+		}
+		s.write(conn, res)
+	}
+}
+
+func (s *fakeServer) write(conn net.Conn, res dto.Response) {
+	b, _ := json.Marshal(res)
+	conn.Write(append(b, '\n'))
+}
+
+// last returns the connection accepted last.
+func (s *fakeServer) last() net.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns[len(s.conns)-1]
+}
+
+func newTestClient(addr string) *Client {
+	return NewClient(WithClientCfg(ClientConfig{
+		Network:        "tcp",
+		Address:        addr,
+		Framing:        NewlineFraming,
+		ReadTimeout:    time.Second,
+		WriteTimeout:   time.Second,
+		ConnectTimeout: time.Second,
+	}))
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	srv := newFakeServer(t)
+
+	tests := []struct {
+		name string
+		// breaks the connection the client made
+		drop func(t *testing.T, c *Client)
+	}{
+		{"after close", func(t *testing.T, c *Client) {
+			if err := c.Close(); err != nil {
+				t.Fatalf("Close() = %v", err)
+			}
+		}},
+		{"after the server closed", func(t *testing.T, c *Client) {
+			srv.last().Close()
+			waitFor(t, "disconnect", func() bool { return !c.IsConnected() })
+		}},
+		{"after goaway", func(t *testing.T, c *Client) {
+			srv.write(srv.last(), dto.Response{Type: FrameGoAway})
+			waitFor(t, "goaway", func() bool { return !c.IsConnected() })
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(srv.ln.Addr().String())
+			if err := c.Connect(); err != nil {
+				t.Fatalf("Connect() = %v", err)
+			}
+			tt.drop(t, c)
+
+			if err := c.Connect(); err != nil {
+				t.Fatalf("Connect() again = %v", err)
+			}
+			if !c.IsConnected() {
+				t.Fatal("not connected after reconnecting")
+			}
+			if err := c.Close(); err != nil {
+				t.Fatalf("Close() = %v", err)
+			}
+		})
+	}
+}
+
+func TestClientConnectTwice(t *testing.T) {
+	srv := newFakeServer(t)
+
+	c := newTestClient(srv.ln.Addr().String())
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Connect(); err == nil {
+		t.Fatal("Connect() on a connected client succeeded")
+	}
+}
+
+func TestClientReconnectAfterFailedHandshake(t *testing.T) {
+	srv := newFakeServer(t)
+	c := newTestClient(srv.ln.Addr().String())
+
+	srv.fail.Store(true)
+	if err := c.Connect(); err == nil {
+		t.Fatal("Connect() succeeded with a failing handshake")
+	}
+	select {
+	case <-srv.eof:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection of the failed handshake was not closed")
+	}
+
+	srv.fail.Store(false)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect() after a failed handshake = %v", err)
+	}
+	c.Close()
+}
+
+// A connection the server announced to close is still open until the
+// server closes it, Close must not leave it behind.
+func TestClientCloseAfterGoAway(t *testing.T) {
+	srv := newFakeServer(t)
+	c := newTestClient(srv.ln.Addr().String())
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+
+	srv.write(srv.last(), dto.Response{Type: FrameGoAway})
+	waitFor(t, "goaway", func() bool { return !c.IsConnected() })
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	select {
+	case <-srv.eof:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() didn't close the connection")
+	}
+}
@@ -0,0 +1,50 @@
+package codec
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	JSON     = "json"
+	MsgPack  = "msgpack"
+	Protobuf = "protobuf"
+)
+
+// Codec encodes request and response envelopes and the payloads they
+// carry. Every connection uses exactly one codec at a time.
+type Codec interface {
+	Name() string
+	// Binary reports whether encoded messages may contain newlines, in
+	// which case they can only be sent with length-prefixed framing.
+	Binary() bool
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var codecs = map[string]Codec{
+	JSON:     jsonCodec{},
+	MsgPack:  msgpackCodec{},
+	Protobuf: protobufCodec{},
+}
+
+func Get(name string) (Codec, error) {
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec: %q", name)
+	}
+	return c, nil
+}
+
+func Default() Codec {
+	return codecs[JSON]
+}
+
+func Names() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
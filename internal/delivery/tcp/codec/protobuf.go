@@ -0,0 +1,531 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// protobufCodec encodes DTOs in the protobuf wire format using the field
+// numbers from their `proto:"N"` struct tags, see dto/dto.proto for the
+// matching schema. Values that are not messages, like the id returned by
+// a create request or the slice returned by a list request, are wrapped
+// in field 1 of an implicit message. Times are google.protobuf.Timestamp
+// messages. Slices with nil elements can't be encoded.
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return Protobuf }
+
+func (protobufCodec) Binary() bool { return true }
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	if isMessage(rv.Type()) {
+		return appendMessage(nil, rv)
+	}
+	return appendField(nil, 1, rv, false)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("protobuf: unmarshal target must be a non-nil pointer")
+	}
+	rv = rv.Elem()
+
+	if isMessage(rv.Type()) {
+		return consumeMessage(data, rv)
+	}
+	return consumeWrapper(data, rv)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func isMessage(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+type protoField struct {
+	index int
+	num   protowire.Number
+}
+
+var fieldCache sync.Map // reflect.Type -> []protoField
+
+func messageFields(t reflect.Type) ([]protoField, error) {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]protoField), nil
+	}
+
+	var fields []protoField
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("proto")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		num, err := strconv.Atoi(tag)
+		if err != nil || num < 1 {
+			return nil, fmt.Errorf("protobuf: invalid field number %q on %s.%s", tag, t, t.Field(i).Name)
+		}
+		fields = append(fields, protoField{index: i, num: protowire.Number(num)})
+	}
+
+	fieldCache.Store(t, fields)
+	return fields, nil
+}
+
+func appendMessage(b []byte, rv reflect.Value) ([]byte, error) {
+	fields, err := messageFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		b, err = appendField(b, f.num, rv.Field(f.index), false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// appendField encodes rv as field num. Zero values are skipped like in
+// proto3 unless the value is an element of a repeated field.
+func appendField(b []byte, num protowire.Number, rv reflect.Value, repeated bool) ([]byte, error) {
+	if !repeated && rv.IsZero() {
+		return b, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return b, nil
+		}
+		return appendField(b, num, rv.Elem(), repeated)
+	case reflect.Bool:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, protowire.EncodeBool(rv.Bool())), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, uint64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, rv.Uint()), nil
+	case reflect.Float32:
+		b = protowire.AppendTag(b, num, protowire.Fixed32Type)
+		return protowire.AppendFixed32(b, math.Float32bits(float32(rv.Float()))), nil
+	case reflect.Float64:
+		b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+		return protowire.AppendFixed64(b, math.Float64bits(rv.Float())), nil
+	case reflect.String:
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendString(b, rv.String()), nil
+	case reflect.Slice:
+		return appendSlice(b, num, rv)
+	case reflect.Map:
+		return appendMap(b, num, rv)
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			return protowire.AppendBytes(b, appendTimestamp(nil, rv.Interface().(time.Time))), nil
+		}
+		msg, err := appendMessage(nil, rv)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, msg), nil
+	default:
+		return nil, fmt.Errorf("protobuf: unsupported type %s", rv.Type())
+	}
+}
+
+func appendSlice(b []byte, num protowire.Number, rv reflect.Value) ([]byte, error) {
+	elem := rv.Type().Elem()
+	if elem.Kind() == reflect.Uint8 {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, rv.Bytes()), nil
+	}
+
+	if isPackable(elem) {
+		var packed []byte
+		for i := 0; i < rv.Len(); i++ {
+			packed = appendPacked(packed, rv.Index(i))
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, packed), nil
+	}
+
+	var err error
+	for i := 0; i < rv.Len(); i++ {
+		// a repeated field has no way to hold nil, skipping it would
+		// move the elements after it
+		if el := rv.Index(i); (el.Kind() == reflect.Ptr || el.Kind() == reflect.Interface) && el.IsNil() {
+			return nil, fmt.Errorf("protobuf: element %d of %s is nil", i, rv.Type())
+		}
+		if b, err = appendField(b, num, rv.Index(i), true); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// appendMap encodes every entry as a message with the key in field 1 and
+// the value in field 2, the same layout protoc uses for map fields.
+func appendMap(b []byte, num protowire.Number, rv reflect.Value) ([]byte, error) {
+	iter := rv.MapRange()
+	for iter.Next() {
+		entry, err := appendField(nil, 1, iter.Key(), false)
+		if err != nil {
+			return nil, err
+		}
+		if entry, err = appendField(entry, 2, iter.Value(), false); err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b, nil
+}
+
+func isPackable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func appendPacked(b []byte, rv reflect.Value) []byte {
+	switch rv.Kind() {
+	case reflect.Bool:
+		return protowire.AppendVarint(b, protowire.EncodeBool(rv.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return protowire.AppendVarint(b, uint64(rv.Int()))
+	case reflect.Float32:
+		return protowire.AppendFixed32(b, math.Float32bits(float32(rv.Float())))
+	case reflect.Float64:
+		return protowire.AppendFixed64(b, math.Float64bits(rv.Float()))
+	default:
+		return protowire.AppendVarint(b, rv.Uint())
+	}
+}
+
+func consumeMessage(b []byte, rv reflect.Value) error {
+	fields, err := messageFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var target reflect.Value
+		for _, f := range fields {
+			if f.num == num {
+				target = rv.Field(f.index)
+				break
+			}
+		}
+
+		if !target.IsValid() {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		} else {
+			n, err = consumeField(b, typ, target)
+			if err != nil {
+				return err
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeWrapper(b []byte, rv reflect.Value) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if num == 1 {
+			var err error
+			if n, err = consumeField(b, typ, rv); err != nil {
+				return err
+			}
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// consumeField decodes one field value of wire type typ into rv and
+// returns the number of bytes read.
+func consumeField(b []byte, typ protowire.Type, rv reflect.Value) (int, error) {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return consumeField(b, typ, rv.Elem())
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return consumeScalar(b, typ, rv)
+	case reflect.String:
+		if typ != protowire.BytesType {
+			return 0, wireTypeError(rv, typ)
+		}
+		v, n := protowire.ConsumeString(b)
+		if n >= 0 {
+			rv.SetString(v)
+		}
+		return n, nil
+	case reflect.Slice:
+		return consumeSlice(b, typ, rv)
+	case reflect.Map:
+		return consumeMapEntry(b, typ, rv)
+	case reflect.Struct:
+		if typ != protowire.BytesType {
+			return 0, wireTypeError(rv, typ)
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n, nil
+		}
+		if rv.Type() == timeType {
+			return n, consumeTimestamp(v, rv)
+		}
+		return n, consumeMessage(v, rv)
+	case reflect.Interface:
+		// the concrete type is unknown here, so keep the raw bytes
+		if typ != protowire.BytesType {
+			return 0, wireTypeError(rv, typ)
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n >= 0 {
+			rv.Set(reflect.ValueOf(append([]byte(nil), v...)))
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("protobuf: unsupported type %s", rv.Type())
+	}
+}
+
+func consumeScalar(b []byte, typ protowire.Type, rv reflect.Value) (int, error) {
+	switch rv.Kind() {
+	case reflect.Float32:
+		if typ != protowire.Fixed32Type {
+			return 0, wireTypeError(rv, typ)
+		}
+		v, n := protowire.ConsumeFixed32(b)
+		if n >= 0 {
+			rv.SetFloat(float64(math.Float32frombits(v)))
+		}
+		return n, nil
+	case reflect.Float64:
+		if typ != protowire.Fixed64Type {
+			return 0, wireTypeError(rv, typ)
+		}
+		v, n := protowire.ConsumeFixed64(b)
+		if n >= 0 {
+			rv.SetFloat(math.Float64frombits(v))
+		}
+		return n, nil
+	}
+
+	if typ != protowire.VarintType {
+		return 0, wireTypeError(rv, typ)
+	}
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return n, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		rv.SetBool(protowire.DecodeBool(v))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		rv.SetInt(int64(v))
+	default:
+		rv.SetUint(v)
+	}
+	return n, nil
+}
+
+func consumeSlice(b []byte, typ protowire.Type, rv reflect.Value) (int, error) {
+	elem := rv.Type().Elem()
+	if elem.Kind() == reflect.Uint8 {
+		if typ != protowire.BytesType {
+			return 0, wireTypeError(rv, typ)
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n >= 0 {
+			rv.SetBytes(append([]byte(nil), v...))
+		}
+		return n, nil
+	}
+
+	// packed scalars arrive as one length-delimited run
+	if isPackable(elem) && typ == protowire.BytesType {
+		packed, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n, nil
+		}
+		elemTyp := protowire.VarintType
+		switch elem.Kind() {
+		case reflect.Float32:
+			elemTyp = protowire.Fixed32Type
+		case reflect.Float64:
+			elemTyp = protowire.Fixed64Type
+		}
+		for len(packed) > 0 {
+			v := reflect.New(elem).Elem()
+			m, err := consumeScalar(packed, elemTyp, v)
+			if err != nil {
+				return 0, err
+			}
+			if m < 0 {
+				return m, nil
+			}
+			rv.Set(reflect.Append(rv, v))
+			packed = packed[m:]
+		}
+		return n, nil
+	}
+
+	v := reflect.New(elem).Elem()
+	n, err := consumeField(b, typ, v)
+	if err != nil || n < 0 {
+		return n, err
+	}
+	rv.Set(reflect.Append(rv, v))
+	return n, nil
+}
+
+func consumeMapEntry(b []byte, typ protowire.Type, rv reflect.Value) (int, error) {
+	if typ != protowire.BytesType {
+		return 0, wireTypeError(rv, typ)
+	}
+	entry, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n, nil
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+	key := reflect.New(rv.Type().Key()).Elem()
+	val := reflect.New(rv.Type().Elem()).Elem()
+
+	for len(entry) > 0 {
+		num, etyp, m := protowire.ConsumeTag(entry)
+		if m < 0 {
+			return m, nil
+		}
+		entry = entry[m:]
+
+		var err error
+		switch num {
+		case 1:
+			m, err = consumeField(entry, etyp, key)
+		case 2:
+			m, err = consumeField(entry, etyp, val)
+		default:
+			m = protowire.ConsumeFieldValue(num, etyp, entry)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if m < 0 {
+			return m, nil
+		}
+		entry = entry[m:]
+	}
+
+	rv.SetMapIndex(key, val)
+	return n, nil
+}
+
+func wireTypeError(rv reflect.Value, typ protowire.Type) error {
+	return fmt.Errorf("protobuf: cannot decode wire type %d into %s", typ, rv.Type())
+}
+
+// appendTimestamp encodes t as a google.protobuf.Timestamp message.
+func appendTimestamp(b []byte, t time.Time) []byte {
+	if secs := t.Unix(); secs != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(secs))
+	}
+	if nanos := t.Nanosecond(); nanos != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(nanos))
+	}
+	return b
+}
+
+func consumeTimestamp(b []byte, rv reflect.Value) error {
+	var secs, nanos int64
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if (num == 1 || num == 2) && typ == protowire.VarintType {
+			v, m := protowire.ConsumeVarint(b)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+			if num == 1 {
+				secs = int64(v)
+			} else {
+				nanos = int64(int32(v))
+			}
+			n = m
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+
+	rv.Set(reflect.ValueOf(time.Unix(secs, nanos)))
+	return nil
+}
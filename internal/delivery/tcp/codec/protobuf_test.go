@@ -0,0 +1,408 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dtos has a value of every struct in the dto package,
+// TestEveryDTOIsTested fails when one is missing.
+var dtos = []any{
+	dto.Request{}, dto.Response{}, dto.SetCodecReq{}, dto.HelloReq{}, dto.HelloRes{},
+	dto.BatchReq{}, dto.StreamReq{}, dto.ListReq{}, dto.IdReq{},
+	dto.School{}, dto.SchoolPage{}, dto.CreateSchoolReq{}, dto.UpdateSchoolReq{},
+	dto.Person{}, dto.PersonPage{}, dto.ListPersonsReq{}, dto.StreamPersonsReq{},
+	dto.CreatePersonReq{}, dto.SetPasswordReq{}, dto.ChangePasswordReq{}, dto.LoginReq{},
+	dto.UpdatePersonReq{},
+	dto.Class{}, dto.ClassPage{}, dto.ListClassesReq{}, dto.StreamClassesReq{},
+	dto.CreateClassReq{}, dto.AddStudentToClassReq{}, dto.UpdateClassReq{},
+	dto.Event{}, dto.SubscribeReq{}, dto.PurgeRes{}, dto.SearchReq{}, dto.SearchResult{},
+}
+
+// wrapperMessages are in dto.proto for the values that are not messages,
+// see the comment at its top.
+var wrapperMessages = []string{
+	"UInt64Value", "StringValue", "SchoolList", "PersonList", "ClassList", "SearchResultList",
+}
+
+var timestamp = time.Unix(1700000000, 123456789)
+
+// fill sets every field of rv to a value that isn't zero, so none of them
+// is skipped on the wire. n makes the values differ between fields.
+func fill(rv reflect.Value, n *int) {
+	*n++
+	switch rv.Kind() {
+	case reflect.Ptr:
+		rv.Set(reflect.New(rv.Type().Elem()))
+		fill(rv.Elem(), n)
+	case reflect.Bool:
+		rv.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		rv.SetInt(-int64(*n))
+	case reflect.Uint, reflect.Uint64:
+		rv.SetUint(uint64(*n))
+	case reflect.Float64:
+		rv.SetFloat(float64(*n) + 0.5)
+	case reflect.String:
+		rv.SetString("value " + strconv.Itoa(*n))
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes([]byte(fmt.Sprintf(`{"n":%d}`, *n)))
+			return
+		}
+		rv.Set(reflect.MakeSlice(rv.Type(), 2, 2))
+		for i := 0; i < rv.Len(); i++ {
+			fill(rv.Index(i), n)
+		}
+	case reflect.Map:
+		rv.Set(reflect.MakeMap(rv.Type()))
+		k, v := reflect.New(rv.Type().Key()).Elem(), reflect.New(rv.Type().Elem()).Elem()
+		fill(k, n)
+		fill(v, n)
+		rv.SetMapIndex(k, v)
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			rv.Set(reflect.ValueOf(timestamp.Add(time.Duration(*n) * time.Second)))
+			return
+		}
+		for i := 0; i < rv.NumField(); i++ {
+			if rv.Type().Field(i).Tag.Get("proto") != "" {
+				fill(rv.Field(i), n)
+			}
+		}
+	default:
+		panic(fmt.Sprintf("fill: unsupported type %s", rv.Type()))
+	}
+}
+
+func TestProtobufRoundTrip(t *testing.T) {
+	c := protobufCodec{}
+
+	for _, v := range dtos {
+		typ := reflect.TypeOf(v)
+		t.Run(typ.Name(), func(t *testing.T) {
+			for _, empty := range []bool{true, false} {
+				want := reflect.New(typ)
+				if !empty {
+					var n int
+					fill(want.Elem(), &n)
+				}
+
+				data, err := c.Marshal(want.Interface())
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := reflect.New(typ)
+				if err := c.Unmarshal(data, got.Interface()); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got.Interface(), want.Interface()) {
+					t.Fatalf("got  %+v\nwant %+v", got.Elem(), want.Elem())
+				}
+			}
+		})
+	}
+}
+
+func TestProtobufWrappedValues(t *testing.T) {
+	c := protobufCodec{}
+
+	tests := []any{
+		uint(42),
+		"a string",
+		[]dto.School{{Id: 1, Name: "a"}, {Id: 2, Name: "b", DeletedAt: &timestamp}},
+		[]dto.SearchResult{{Kind: "school", Id: 1, Name: "a", Rank: -1.5}},
+	}
+
+	for _, want := range tests {
+		data, err := c.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := reflect.New(reflect.TypeOf(want))
+		if err := c.Unmarshal(data, got.Interface()); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Elem().Interface(), want) {
+			t.Errorf("got %+v, want %+v", got.Elem(), want)
+		}
+	}
+}
+
+// Repeated fields can't hold nil, so a nil element fails the encoding
+// instead of being dropped.
+func TestProtobufNilElements(t *testing.T) {
+	c := protobufCodec{}
+	school := &dto.School{Id: 1}
+
+	tests := []any{
+		[]*dto.School{school, nil, school},
+		struct {
+			Schools []*dto.School `proto:"1"`
+		}{Schools: []*dto.School{nil}},
+		[]any{"a", nil},
+	}
+
+	for _, v := range tests {
+		if data, err := c.Marshal(v); err == nil {
+			t.Errorf("Marshal(%#v) = %x, want an error", v, data)
+		}
+	}
+
+	if _, err := c.Marshal([]*dto.School{school, school}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Times must be encoded like protoc encodes a google.protobuf.Timestamp.
+func TestProtobufTimestamp(t *testing.T) {
+	type message struct {
+		At time.Time `proto:"1"`
+	}
+
+	for _, at := range []time.Time{
+		timestamp,
+		time.Unix(1700000000, 0),
+		time.Unix(0, 1),
+		time.Unix(-1, 500),
+	} {
+		data, err := protobufCodec{}.Marshal(message{At: at})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ts, err := proto.Marshal(timestamppb.New(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := protowire.AppendTag(nil, 1, protowire.BytesType)
+		want = protowire.AppendBytes(want, ts)
+		if !bytes.Equal(data, want) {
+			t.Errorf("%v: got %x, want %x", at, data, want)
+		}
+
+		var got message
+		if err := (protobufCodec{}).Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !got.At.Equal(at) {
+			t.Errorf("got %v, want %v", got.At, at)
+		}
+	}
+}
+
+type schemaField struct {
+	typ      string
+	name     string
+	num      int
+	repeated bool
+}
+
+var (
+	messageRe = regexp.MustCompile(`^message (\w+) \{$`)
+	fieldRe   = regexp.MustCompile(`^(repeated )?(map<\w+, \w+>|[\w.]+) (\w+) = (\d+);`)
+)
+
+// parseProto returns the fields of every message in the schema.
+func parseProto(t *testing.T, path string) map[string][]schemaField {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := make(map[string][]schemaField)
+	var current string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if m := messageRe.FindStringSubmatch(line); m != nil {
+			current = m[1]
+			messages[current] = nil
+			continue
+		}
+		if line == "}" {
+			current = ""
+			continue
+		}
+		if m := fieldRe.FindStringSubmatch(line); m != nil && current != "" {
+			num, _ := strconv.Atoi(m[4])
+			messages[current] = append(messages[current], schemaField{
+				typ: m[2], name: m[3], num: num, repeated: m[1] != "",
+			})
+		}
+	}
+	return messages
+}
+
+// protoType returns the schema type of a Go field type.
+func protoType(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return protoType(e.X)
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return "bytes", false
+		}
+		typ, _ := protoType(e.Elt)
+		return typ, true
+	case *ast.MapType:
+		k, _ := protoType(e.Key)
+		v, _ := protoType(e.Value)
+		return fmt.Sprintf("map<%s, %s>", k, v), false
+	case *ast.SelectorExpr:
+		switch fmt.Sprintf("%s.%s", e.X, e.Sel) {
+		case "json.RawMessage":
+			return "bytes", false
+		case "time.Time":
+			return "google.protobuf.Timestamp", false
+		}
+	case *ast.Ident:
+		switch e.Name {
+		case "int", "int64":
+			return "int64", false
+		case "uint", "uint64":
+			return "uint64", false
+		case "float64":
+			return "double", false
+		}
+		return e.Name, false
+	}
+	return fmt.Sprintf("%T", expr), false
+}
+
+type goField struct {
+	name string
+	tag  reflect.StructTag
+	expr ast.Expr
+}
+
+// parseDTOs returns the fields of every struct in the dto package.
+func parseDTOs(t *testing.T, dir string) map[string][]goField {
+	t.Helper()
+
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structs := make(map[string][]goField)
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			var fields []goField
+			for _, f := range st.Fields.List {
+				var tag reflect.StructTag
+				if f.Tag != nil {
+					tag = reflect.StructTag(strings.Trim(f.Tag.Value, "`"))
+				}
+				for _, name := range f.Names {
+					fields = append(fields, goField{name: name.Name, tag: tag, expr: f.Type})
+				}
+			}
+			structs[spec.Name.Name] = fields
+			return false
+		})
+	}
+	return structs
+}
+
+func TestEveryDTOIsTested(t *testing.T) {
+	var tested []string
+	for _, v := range dtos {
+		tested = append(tested, reflect.TypeOf(v).Name())
+	}
+
+	for name := range parseDTOs(t, "../dto") {
+		if !slices.Contains(tested, name) {
+			t.Errorf("dto.%s is missing from dtos", name)
+		}
+	}
+}
+
+// The struct tags must agree with dto.proto on the number, name and type
+// of every field, and dto.proto can't have fields the DTOs don't.
+func TestDTOsMatchProtoSchema(t *testing.T) {
+	messages := parseProto(t, "../dto/dto.proto")
+	structs := parseDTOs(t, "../dto")
+
+	for name, fields := range structs {
+		message, ok := messages[name]
+		if !ok {
+			t.Errorf("dto.%s has no message in dto.proto", name)
+			continue
+		}
+
+		seen := make(map[int]bool)
+		for _, f := range fields {
+			tag := f.tag.Get("proto")
+			if tag == "" {
+				t.Errorf("dto.%s.%s has no proto tag", name, f.name)
+				continue
+			}
+			num, err := strconv.Atoi(tag)
+			if err != nil {
+				t.Errorf("dto.%s.%s has proto tag %q", name, f.name, tag)
+				continue
+			}
+			if seen[num] {
+				t.Errorf("dto.%s uses field number %d twice", name, num)
+			}
+			seen[num] = true
+
+			i := slices.IndexFunc(message, func(pf schemaField) bool { return pf.num == num })
+			if i < 0 {
+				t.Errorf("dto.%s.%s is field %d, which %s in dto.proto doesn't have", name, f.name, num, name)
+				continue
+			}
+			pf := message[i]
+
+			jsonName, _, _ := strings.Cut(f.tag.Get("json"), ",")
+			if pf.name != jsonName {
+				t.Errorf("dto.%s.%s is %q in json and %q in dto.proto", name, f.name, jsonName, pf.name)
+			}
+			typ, repeated := protoType(f.expr)
+			if pf.typ != typ || pf.repeated != repeated {
+				t.Errorf("dto.%s.%s is %s (repeated %v) in dto.proto, want %s (repeated %v)",
+					name, f.name, pf.typ, pf.repeated, typ, repeated)
+			}
+		}
+
+		for _, pf := range message {
+			if !seen[pf.num] {
+				t.Errorf("%s.%s = %d in dto.proto has no field in dto.%s", name, pf.name, pf.num, name)
+			}
+		}
+	}
+
+	for name := range messages {
+		if _, ok := structs[name]; !ok && !slices.Contains(wrapperMessages, name) {
+			t.Errorf("message %s in dto.proto has no DTO", name)
+		}
+	}
+}
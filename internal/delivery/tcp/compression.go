@@ -0,0 +1,70 @@
+package tcp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compression is applied to every frame body once it is negotiated with
+// hello. Compressed frames are binary, so they need length framing.
+type Compression string
+
+const (
+	NoCompression   Compression = "none"
+	GzipCompression Compression = "gzip"
+)
+
+// Compressions returns the supported compressions in order of preference.
+func Compressions() []Compression {
+	return []Compression{GzipCompression, NoCompression}
+}
+
+func (c Compression) supported() bool {
+	return c == NoCompression || c == GzipCompression
+}
+
+func (c Compression) compress(data []byte) ([]byte, error) {
+	if c != GzipCompression {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress reverses compress. A maxSize above zero bounds the size of
+// the decompressed body, like it bounds frames.
+func (c Compression) decompress(data []byte, maxSize int64) ([]byte, error) {
+	if c != GzipCompression {
+		return data, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip frame: %w", err)
+	}
+	defer r.Close()
+
+	var src io.Reader = r
+	if maxSize > 0 {
+		src = io.LimitReader(r, maxSize+1)
+	}
+
+	body, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip frame: %w", err)
+	}
+	if maxSize > 0 && int64(len(body)) > maxSize {
+		return nil, ErrFrameTooLarge
+	}
+	return body, nil
+}
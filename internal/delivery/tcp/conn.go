@@ -0,0 +1,112 @@
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/codec"
+)
+
+// connection wraps an accepted net.Conn with the state the server keeps
+// for it while requests on it are processed concurrently.
+type connection struct {
+	net.Conn
+	id   uint64
+	addr string
+	// the listener the connection was accepted on, for its timeouts
+	cfg *ListenerCfg
+
+	// only changed by the read loop before the first request is
+	// dispatched, codec and compression under wmu
+	codec       codec.Codec
+	compression Compression
+	version     int
+	greeted     bool
+	dispatched  int
+
+	// serializes writes so concurrent responses never interleave
+	wmu sync.Mutex
+
+	// requests currently being processed on this connection
+	inflight sync.WaitGroup
+	active   atomic.Int64
+	slots    chan struct{}
+
+	// set on shutdown, the read loop stops taking new requests
+	draining atomic.Bool
+
+	// nil until the client subscribes to events
+	subMu sync.Mutex
+	sub   *subscription
+
+	// closed once the connection is done
+	done chan struct{}
+
+	heartbeat heartbeat
+
+	// who the client logged in as
+	session session
+}
+
+func newConnection(id uint64, conn net.Conn, cfg *ListenerCfg, maxInFlight int) *connection {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+
+	// unix socket clients are usually unnamed
+	addr := conn.RemoteAddr().String()
+	if addr == "" || addr == "@" {
+		addr = fmt.Sprintf("%s#%d", conn.LocalAddr().Network(), id)
+	}
+
+	return &connection{
+		Conn:        conn,
+		id:          id,
+		addr:        addr,
+		cfg:         cfg,
+		codec:       codec.Default(),
+		compression: NoCompression,
+		version:     MinProtocolVersion,
+		slots:       make(chan struct{}, maxInFlight),
+		done:        make(chan struct{}),
+	}
+}
+
+// switchTo changes the codec and compression of the frames written after
+// it returns.
+func (c *connection) switchTo(cd codec.Codec, compression Compression) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.codec = cd
+	c.compression = compression
+}
+
+// armReadDeadline gives the client the read timeout to send its next
+// frame. While requests are in flight the client is waiting on us, not
+// idle, so there is no deadline until finish sets one for the last of
+// them. A timed out read loses the part of a frame it got and is only
+// allowed to happen to a connection that gets closed for it.
+func (c *connection) armReadDeadline() {
+	c.SetReadDeadline(time.Time{})
+	if c.active.Load() == 0 {
+		c.SetReadDeadline(time.Now().Add(c.cfg.ReadTimeout))
+	}
+}
+
+// finish marks a request of the connection as done.
+func (c *connection) finish() {
+	// draining already woke the read loop up
+	if c.active.Add(-1) == 0 && !c.draining.Load() {
+		c.SetReadDeadline(time.Now().Add(c.cfg.ReadTimeout))
+	}
+}
+
+// drain asks the read loop to stop reading requests. Requests already
+// dispatched still complete.
+func (c *connection) drain() {
+	c.draining.Store(true)
+	c.SetReadDeadline(time.Now())
+}
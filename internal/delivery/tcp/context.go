@@ -0,0 +1,52 @@
+package tcp
+
+import (
+	"context"
+	"crypto/x509/pkix"
+)
+
+type ctxKey int
+
+const (
+	clientCertSubjectKey ctxKey = iota
+	peerCredentialsKey
+	requestInfoKey
+)
+
+// RequestInfo describes the request a handler is running for.
+type RequestInfo struct {
+	Id         string
+	Type       RequestType
+	RemoteAddr string
+}
+
+func withRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey).(RequestInfo)
+	return info, ok
+}
+
+func withClientCertSubject(ctx context.Context, subject pkix.Name) context.Context {
+	return context.WithValue(ctx, clientCertSubjectKey, subject)
+}
+
+// ClientCertSubject returns the subject of the verified certificate the
+// client presented with mutual TLS.
+func ClientCertSubject(ctx context.Context) (pkix.Name, bool) {
+	subject, ok := ctx.Value(clientCertSubjectKey).(pkix.Name)
+	return subject, ok
+}
+
+func withPeerCredentials(ctx context.Context, cred PeerCredentials) context.Context {
+	return context.WithValue(ctx, peerCredentialsKey, cred)
+}
+
+// PeerCreds returns the credentials of the process connected over a unix
+// socket (SO_PEERCRED).
+func PeerCreds(ctx context.Context) (PeerCredentials, bool) {
+	cred, ok := ctx.Value(peerCredentialsKey).(PeerCredentials)
+	return cred, ok
+}
@@ -1,20 +1,59 @@
 package dto
 
+import "time"
+
 type Class struct {
-	Id       uint     `json:"id,omitempty"`
-	Name     string   `json:"name,omitempty"`
-	SchoolId uint     `json:"school_id,omitempty"`
-	Teacher  Person   `json:"teacher,omitempty"`
-	Students []Person `json:"students,omitempty"`
+	Id        uint       `json:"id,omitempty" proto:"1"`
+	Name      string     `json:"name,omitempty" proto:"2"`
+	SchoolId  uint       `json:"school_id,omitempty" proto:"3"`
+	Teacher   Person     `json:"teacher,omitempty" proto:"4"`
+	Students  []Person   `json:"students,omitempty" proto:"5"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" proto:"6"`
+}
+
+type ClassPage struct {
+	Items      []Class `json:"items" proto:"1"`
+	NextCursor string  `json:"next_cursor,omitempty" proto:"2"`
+}
+
+// ListClassesReq has the fields of ListReq, SchoolId and TeacherId only
+// list the classes that match them.
+type ListClassesReq struct {
+	IncludeDeleted bool   `json:"include_deleted,omitempty" proto:"1"`
+	PageSize       int    `json:"page_size,omitempty" proto:"2"`
+	Cursor         string `json:"cursor,omitempty" proto:"3"`
+	Sort           string `json:"sort,omitempty" proto:"4"`
+	NamePrefix     string `json:"name_prefix,omitempty" proto:"5"`
+	SchoolId       uint   `json:"school_id,omitempty" proto:"6"`
+	TeacherId      uint   `json:"teacher_id,omitempty" proto:"7"`
+}
+
+// StreamClassesReq has the fields of StreamReq, SchoolId and TeacherId
+// only stream the classes that match them.
+type StreamClassesReq struct {
+	ChunkSize      int    `json:"chunk_size,omitempty" proto:"1"`
+	IncludeDeleted bool   `json:"include_deleted,omitempty" proto:"2"`
+	Sort           string `json:"sort,omitempty" proto:"3"`
+	NamePrefix     string `json:"name_prefix,omitempty" proto:"4"`
+	SchoolId       uint   `json:"school_id,omitempty" proto:"5"`
+	TeacherId      uint   `json:"teacher_id,omitempty" proto:"6"`
 }
 
 type CreateClassReq struct {
-	Name      string `json:"name,omitempty"`
-	SchoolId  uint   `json:"school_id,omitempty"`
-	TeacherId uint   `json:"teacher_id,omitempty"`
+	Name      string `json:"name,omitempty" proto:"1"`
+	SchoolId  uint   `json:"school_id,omitempty" proto:"2"`
+	TeacherId uint   `json:"teacher_id,omitempty" proto:"3"`
 }
 
 type AddStudentToClassReq struct {
-	StudentId uint `json:"student_id,omitempty"`
-	ClassId   uint `json:"class_id,omitempty"`
+	StudentId uint `json:"student_id,omitempty" proto:"1"`
+	ClassId   uint `json:"class_id,omitempty" proto:"2"`
+}
+
+// UpdateClassReq leaves the fields it doesn't set unchanged, the reply is
+// the updated Class.
+type UpdateClassReq struct {
+	Id        uint   `json:"id,omitempty" proto:"1"`
+	Name      string `json:"name,omitempty" proto:"2"`
+	TeacherId uint   `json:"teacher_id,omitempty" proto:"3"`
 }
@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+type Event struct {
+	Type       string    `json:"type,omitempty" proto:"1"`
+	OccurredAt time.Time `json:"occurred_at" proto:"2"`
+	SchoolId   uint      `json:"school_id,omitempty" proto:"3"`
+	PersonId   uint      `json:"person_id,omitempty" proto:"4"`
+	ClassId    uint      `json:"class_id,omitempty" proto:"5"`
+}
+
+// SubscribeReq lists the event types to receive, every type when empty.
+type SubscribeReq struct {
+	Events []string `json:"events,omitempty" proto:"1"`
+}
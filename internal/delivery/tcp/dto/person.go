@@ -1,19 +1,75 @@
 package dto
 
+import "time"
+
 type Person struct {
-	Id      uint   `json:"id,omitempty"`
-	Name    string `json:"name,omitempty"`
-	Role    string `json:"role,omitempty"`
-	SchoolId uint  `json:"school_id,omitempty"`
-	Classes []uint `json:"classes,omitempty"`
+	Id        uint       `json:"id,omitempty" proto:"1"`
+	Name      string     `json:"name,omitempty" proto:"2"`
+	Role      string     `json:"role,omitempty" proto:"3"`
+	SchoolId  uint       `json:"school_id,omitempty" proto:"4"`
+	Classes   []uint     `json:"classes,omitempty" proto:"5"`
+	School    *School    `json:"school,omitempty" proto:"6"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" proto:"7"`
+}
+
+type PersonPage struct {
+	Items      []Person `json:"items" proto:"1"`
+	NextCursor string   `json:"next_cursor,omitempty" proto:"2"`
+}
+
+// ListPersonsReq has the fields of ListReq, Role and SchoolId only list
+// the persons that match them.
+type ListPersonsReq struct {
+	IncludeDeleted bool   `json:"include_deleted,omitempty" proto:"1"`
+	PageSize       int    `json:"page_size,omitempty" proto:"2"`
+	Cursor         string `json:"cursor,omitempty" proto:"3"`
+	Sort           string `json:"sort,omitempty" proto:"4"`
+	NamePrefix     string `json:"name_prefix,omitempty" proto:"5"`
+	Role           string `json:"role,omitempty" proto:"6"`
+	SchoolId       uint   `json:"school_id,omitempty" proto:"7"`
+}
+
+// StreamPersonsReq has the fields of StreamReq, Role and SchoolId only
+// stream the persons that match them.
+type StreamPersonsReq struct {
+	ChunkSize      int    `json:"chunk_size,omitempty" proto:"1"`
+	IncludeDeleted bool   `json:"include_deleted,omitempty" proto:"2"`
+	Sort           string `json:"sort,omitempty" proto:"3"`
+	NamePrefix     string `json:"name_prefix,omitempty" proto:"4"`
+	Role           string `json:"role,omitempty" proto:"5"`
+	SchoolId       uint   `json:"school_id,omitempty" proto:"6"`
 }
 
 type CreatePersonReq struct {
-	Name     string `json:"name,omitempty"`
-	Role     string `json:"role,omitempty"`
-	SchoolId uint   `json:"school_id,omitempty"`
+	Name     string `json:"name,omitempty" proto:"1"`
+	Role     string `json:"role,omitempty" proto:"2"`
+	SchoolId uint   `json:"school_id,omitempty" proto:"3"`
+	// Password is optional, persons without one can't log in
+	Password string `json:"password,omitempty" proto:"4"`
+}
+
+// SetPasswordReq is the payload of set_password and reset_password.
+type SetPasswordReq struct {
+	PersonId uint   `json:"person_id,omitempty" proto:"1"`
+	Password string `json:"password,omitempty" proto:"2"`
+}
+
+// ChangePasswordReq changes the password of the person logged in.
+type ChangePasswordReq struct {
+	OldPassword string `json:"old_password,omitempty" proto:"1"`
+	NewPassword string `json:"new_password,omitempty" proto:"2"`
+}
+
+// LoginReq binds the person to the connection, the reply is the Person.
+type LoginReq struct {
+	PersonId uint   `json:"person_id,omitempty" proto:"1"`
+	Password string `json:"password,omitempty" proto:"2"`
 }
 
-type WhoAmIReq struct {
-	PersonId uint `json:"person_id,omitempty"`
+// UpdatePersonReq leaves the fields it doesn't set unchanged, the reply
+// is the updated Person.
+type UpdatePersonReq struct {
+	Id       uint   `json:"id,omitempty" proto:"1"`
+	Name     string `json:"name,omitempty" proto:"2"`
+	SchoolId uint   `json:"school_id,omitempty" proto:"3"`
 }
@@ -0,0 +1,8 @@
+package dto
+
+// PurgeRes counts the records a purge removed for good.
+type PurgeRes struct {
+	Schools int64 `json:"schools" proto:"1"`
+	Persons int64 `json:"persons" proto:"2"`
+	Classes int64 `json:"classes" proto:"3"`
+}
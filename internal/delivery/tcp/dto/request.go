@@ -2,7 +2,73 @@ package dto
 
 import "encoding/json"
 
+// Payload stays encoded with the codec of the connection the request was
+// sent on until the handler decodes it.
 type Request struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
+	Id      string          `json:"id,omitempty" proto:"1"`
+	Type    string          `json:"type" proto:"2"`
+	Payload json.RawMessage `json:"payload" proto:"3"`
+}
+
+type SetCodecReq struct {
+	Name string `json:"name,omitempty" proto:"1"`
+}
+
+// HelloReq lists codecs and compressions in order of preference. The
+// server picks the first it supports, an empty list keeps the current one.
+type HelloReq struct {
+	Version      int      `json:"version" proto:"1"`
+	Codecs       []string `json:"codecs,omitempty" proto:"2"`
+	Compressions []string `json:"compressions,omitempty" proto:"3"`
+	RequestTypes []string `json:"request_types,omitempty" proto:"4"`
+}
+
+// HelloRes holds what was agreed on. RequestTypes are the requested ones
+// the server supports, or all of them if none were requested.
+type HelloRes struct {
+	Version      int      `json:"version" proto:"1"`
+	Codec        string   `json:"codec,omitempty" proto:"2"`
+	Compression  string   `json:"compression,omitempty" proto:"3"`
+	RequestTypes []string `json:"request_types,omitempty" proto:"4"`
+}
+
+// BatchReq carries requests to run in order. The reply holds a Response
+// per request, with the id of the request. Atomic batches either apply
+// every request or none of them. Batches can't hold other batches or the
+// login and password requests.
+type BatchReq struct {
+	Atomic   bool      `json:"atomic,omitempty" proto:"1"`
+	Requests []Request `json:"requests" proto:"2"`
+}
+
+// StreamReq is the payload of stream_schools, StreamPersonsReq and
+// StreamClassesReq add filters to it. The reply is a chunk frame per batch
+// of at most ChunkSize items, then an end frame. Sort and NamePrefix work
+// like in ListReq.
+type StreamReq struct {
+	ChunkSize      int    `json:"chunk_size,omitempty" proto:"1"`
+	IncludeDeleted bool   `json:"include_deleted,omitempty" proto:"2"`
+	Sort           string `json:"sort,omitempty" proto:"3"`
+	NamePrefix     string `json:"name_prefix,omitempty" proto:"4"`
+}
+
+// ListReq is the payload of list_schools, ListPersonsReq and
+// ListClassesReq add filters to it. The list_* requests also accept the
+// empty payload older clients send. The reply is the bare list unless
+// PageSize is set, then it is a page with the cursor of the next one.
+// Without a PageSize the list holds every item.
+type ListReq struct {
+	// IncludeDeleted also lists soft deleted records, for admins only
+	IncludeDeleted bool   `json:"include_deleted,omitempty" proto:"1"`
+	PageSize       int    `json:"page_size,omitempty" proto:"2"`
+	Cursor         string `json:"cursor,omitempty" proto:"3"`
+	// Sort is id or name, prefixed with "-" for descending order
+	Sort       string `json:"sort,omitempty" proto:"4"`
+	NamePrefix string `json:"name_prefix,omitempty" proto:"5"`
+}
+
+// IdReq is the payload of the requests that only name a record, like
+// delete_school and restore_school.
+type IdReq struct {
+	Id uint `json:"id,omitempty" proto:"1"`
 }
@@ -1,7 +1,17 @@
 package dto
 
+import "encoding/json"
+
+// Data is encoded with the codec of the connection the response is sent
+// on, independently of the envelope. Type is only set on frames the server
+// sends without a matching request. Code and Details are only set when
+// Status is false.
 type Response struct {
-	Status  bool        `json:"status,omitempty"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Id      string            `json:"id,omitempty" proto:"1"`
+	Status  bool              `json:"status,omitempty" proto:"2"`
+	Message string            `json:"message,omitempty" proto:"3"`
+	Data    json.RawMessage   `json:"data,omitempty" proto:"4"`
+	Type    string            `json:"type,omitempty" proto:"5"`
+	Code    string            `json:"code,omitempty" proto:"6"`
+	Details map[string]string `json:"details,omitempty" proto:"7"`
 }
@@ -1,5 +1,25 @@
 package dto
 
+import "time"
+
+type School struct {
+	Id        uint       `json:"id,omitempty" proto:"1"`
+	Name      string     `json:"name,omitempty" proto:"2"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" proto:"3"`
+}
+
+// SchoolPage is the reply to a list_schools with a page size, NextCursor
+// is empty on the last page. The same goes for PersonPage and ClassPage.
+type SchoolPage struct {
+	Items      []School `json:"items" proto:"1"`
+	NextCursor string   `json:"next_cursor,omitempty" proto:"2"`
+}
+
 type CreateSchoolReq struct {
-	Name string `json:"name,omitempty"`
+	Name string `json:"name,omitempty" proto:"1"`
+}
+
+type UpdateSchoolReq struct {
+	Id   uint   `json:"id,omitempty" proto:"1"`
+	Name string `json:"name,omitempty" proto:"2"`
 }
@@ -0,0 +1,18 @@
+package dto
+
+// SearchReq finds records by the start of the words in their names. Kinds
+// is any of school, person and class, all of them when empty.
+type SearchReq struct {
+	Query string   `json:"query,omitempty" proto:"1"`
+	Kinds []string `json:"kinds,omitempty" proto:"2"`
+	Limit int      `json:"limit,omitempty" proto:"3"`
+}
+
+// SearchResult is one match of a search, the reply is a list of them best
+// match first. Lower ranks match better.
+type SearchResult struct {
+	Kind string  `json:"kind,omitempty" proto:"1"`
+	Id   uint    `json:"id,omitempty" proto:"2"`
+	Name string  `json:"name,omitempty" proto:"3"`
+	Rank float64 `json:"rank" proto:"4"`
+}
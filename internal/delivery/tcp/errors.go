@@ -0,0 +1,138 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+// ErrorCode is the machine-readable reason a request failed, sent in the
+// code field of the response.
+type ErrorCode string
+
+const (
+	CodeInvalidArgument    ErrorCode = "INVALID_ARGUMENT"
+	CodeNotFound           ErrorCode = "NOT_FOUND"
+	CodeConflict           ErrorCode = "CONFLICT"
+	CodeUnauthenticated    ErrorCode = "UNAUTHENTICATED"
+	CodePermissionDenied   ErrorCode = "PERMISSION_DENIED"
+	CodeFailedPrecondition ErrorCode = "FAILED_PRECONDITION"
+	CodeUnimplemented      ErrorCode = "UNIMPLEMENTED"
+	CodeDeadlineExceeded   ErrorCode = "DEADLINE_EXCEEDED"
+	CodeAborted            ErrorCode = "ABORTED"
+	CodeRateLimited        ErrorCode = "RATE_LIMITED"
+	CodeInternal           ErrorCode = "INTERNAL"
+)
+
+// Error is an error that is sent to the client as is. Handlers return it
+// when the code can't be derived from the domain error.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Details map[string]string
+}
+
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithDetail returns a copy of e with key set to value in its details.
+func (e *Error) WithDetail(key, value string) *Error {
+	details := make(map[string]string, len(e.Details)+1)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	details[key] = value
+
+	return &Error{Code: e.Code, Message: e.Message, Details: details}
+}
+
+// HasCode reports whether err is an *Error with the given code.
+func HasCode(err error, code ErrorCode) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// RetryAfter returns how long to wait before retrying a request that was
+// rejected with CodeRateLimited.
+func RetryAfter(err error) (time.Duration, bool) {
+	var e *Error
+	if !errors.As(err, &e) || e.Code != CodeRateLimited {
+		return 0, false
+	}
+
+	wait, perr := time.ParseDuration(e.Details["retry_after"])
+	if perr != nil {
+		return 0, false
+	}
+	return wait, true
+}
+
+// toError maps an error returned by a handler to the one sent to the
+// client. Errors that are not part of the domain are reported as INTERNAL
+// without their message, so storage details never leak.
+func toError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	switch {
+	case errors.Is(err, entity.ErrNotFound):
+		return NewError(CodeNotFound, err.Error())
+	case errors.Is(err, entity.ErrConflict),
+		errors.Is(err, entity.ErrInUse):
+		return NewError(CodeConflict, err.Error())
+	case errors.Is(err, entity.ErrInvalidPerson),
+		errors.Is(err, entity.ErrInvalidSchool),
+		errors.Is(err, entity.ErrInvalidClass),
+		errors.Is(err, entity.ErrInvalidListOptions),
+		errors.Is(err, entity.ErrInvalidQuery):
+		return NewError(CodeInvalidArgument, err.Error())
+	case errors.Is(err, entity.ErrNotStudent),
+		errors.Is(err, entity.ErrNotTeacher),
+		errors.Is(err, entity.ErrSchoolMismatch):
+		return NewError(CodeFailedPrecondition, err.Error())
+	case errors.Is(err, entity.ErrInvalidCredentials),
+		errors.Is(err, entity.ErrAccountLocked):
+		return NewError(CodeUnauthenticated, err.Error())
+	case errors.Is(err, entity.ErrPermissionDenied):
+		return NewError(CodePermissionDenied, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewError(CodeDeadlineExceeded, "request timed out")
+	}
+
+	return NewError(CodeInternal, "internal error")
+}
+
+func errorResponse(id string, err *Error) dto.Response {
+	return dto.Response{
+		Id:      id,
+		Status:  false,
+		Code:    string(err.Code),
+		Message: err.Message,
+		Details: err.Details,
+	}
+}
+
+// ResponseError returns the error carried by a failed response, or nil
+// when the request succeeded.
+func ResponseError(res *dto.Response) error {
+	if res.Status {
+		return nil
+	}
+
+	code := ErrorCode(res.Code)
+	if code == "" {
+		code = CodeInternal
+	}
+
+	return &Error{Code: code, Message: res.Message, Details: res.Details}
+}
@@ -0,0 +1,144 @@
+package tcp
+
+import (
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/mapper"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+)
+
+// eventBuffer is how many events can wait for a slow subscriber before
+// new ones are dropped.
+const eventBuffer = 64
+
+type subscription struct {
+	types  map[event.Type]bool
+	events chan event.Event
+}
+
+// notify queues e if the connection subscribed to its type. It reports
+// false when the event had to be dropped.
+func (c *connection) notify(e event.Event) bool {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.sub == nil || !c.sub.types[e.Type] {
+		return true
+	}
+
+	select {
+	case c.sub.events <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// publish fans an event out to every subscribed connection. It runs on
+// the goroutine of the use case that published it, so it never blocks.
+func (s *server) publish(e event.Event) {
+	s.connections.Range(func(_, v any) bool {
+		c := v.(*connection)
+		if !c.notify(e) {
+			s.logger.Printf("Dropping %s event for slow subscriber %s\n", e.Type, c.addr)
+		}
+		return true
+	})
+}
+
+func (s *server) subscribe(c *connection, req dto.Request) {
+	if err := s.authorize(c, RequestType(req.Type)); err != nil {
+		s.write(c, errorResponse(req.Id, err))
+		return
+	}
+	if s.events == nil {
+		s.write(c, errorResponse(req.Id, NewError(CodeUnimplemented, "events are not enabled")))
+		return
+	}
+
+	types := make(map[event.Type]bool)
+	if RequestType(req.Type) == Subscribe {
+		var body dto.SubscribeReq
+		if err := c.codec.Unmarshal(req.Payload, &body); err != nil {
+			s.write(c, errorResponse(req.Id, NewError(CodeInvalidArgument, "invalid payload")))
+			return
+		}
+
+		known := make(map[event.Type]bool)
+		for _, t := range event.Types() {
+			known[t] = true
+		}
+
+		for _, name := range body.Events {
+			if !known[event.Type(name)] {
+				s.write(c, errorResponse(req.Id, NewError(CodeInvalidArgument,
+					fmt.Sprintf("unknown event type: %s", name))))
+				return
+			}
+			types[event.Type(name)] = true
+		}
+		if len(types) == 0 {
+			types = known
+		}
+	}
+
+	c.subMu.Lock()
+	first := c.sub == nil
+	if first {
+		c.sub = &subscription{events: make(chan event.Event, eventBuffer)}
+	}
+	c.sub.types = types
+	events := c.sub.events
+	c.subMu.Unlock()
+
+	if first {
+		go s.pushEvents(c, events)
+	}
+
+	subscribed := []string{}
+	for _, t := range event.Types() {
+		if types[t] {
+			subscribed = append(subscribed, string(t))
+		}
+	}
+
+	data, err := c.codec.Marshal(subscribed)
+	if err != nil {
+		s.write(c, errorResponse(req.Id, NewError(CodeInternal, "failed to encode response")))
+		return
+	}
+
+	s.write(c, dto.Response{
+		Id:     req.Id,
+		Status: true,
+		Data:   data,
+	})
+}
+
+// pushEvents writes queued events to the connection until it is closed.
+func (s *server) pushEvents(c *connection, events <-chan event.Event) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case e := <-events:
+			// nothing but the goaway frame is sent while draining
+			if c.draining.Load() {
+				continue
+			}
+
+			data, err := c.codec.Marshal(mapper.EventToDto(e))
+			if err != nil {
+				s.logger.Printf("Failed to encode %s event: %v\n", e.Type, err)
+				continue
+			}
+
+			s.write(c, dto.Response{
+				Status: true,
+				Data:   data,
+				Type:   FrameEvent,
+			})
+		}
+	}
+}
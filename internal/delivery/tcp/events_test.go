@@ -0,0 +1,131 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+)
+
+func TestEventFrames(t *testing.T) {
+	bus := event.NewBus()
+	cfg := testCfg()
+	cfg.Auth.Allow = []RequestType{CreateSchool, CreatePerson}
+	s := NewServer(
+		WithCfg(cfg),
+		WithLogger(log.New(io.Discard, "", 0)),
+		WithEvents(bus),
+		WithTransactor(fakeTransactor{}),
+	).(*server)
+
+	// create_school publishes an event for the id it's sent, and fails
+	// for a school named "fail"
+	s.RegisterHandler(CreateSchool, func(ctx context.Context, payload Payload) (interface{}, error) {
+		var body dto.School
+		if err := payload.Decode(&body); err != nil {
+			return nil, err
+		}
+		bus.Publish(ctx, event.Event{Type: event.SchoolCreated, SchoolId: body.Id})
+		if body.Name == "fail" {
+			return nil, NewError(CodeInvalidArgument, "failed")
+		}
+		return body, nil
+	})
+	s.RegisterHandler(CreatePerson, func(ctx context.Context, payload Payload) (interface{}, error) {
+		bus.Publish(ctx, event.Event{Type: event.PersonCreated, PersonId: 1})
+		return nil, nil
+	})
+	tc := dialTestConn(t, startTestServer(t, s))
+
+	tc.send(t, "sub", Subscribe, dto.SubscribeReq{Events: []string{string(event.SchoolCreated)}})
+	if res := tc.mustRecv(t); res.Id != "sub" || !res.Status {
+		t.Fatalf("got %+v, want the subscribe reply", res)
+	}
+
+	// readFrames reads until it has the replies to ids and n events, and
+	// returns the school ids of the events
+	readFrames := func(n int, ids ...string) []uint {
+		t.Helper()
+
+		want := make(map[string]bool)
+		for _, id := range ids {
+			want[id] = true
+		}
+		var schools []uint
+		for len(want) > 0 || len(schools) < n {
+			res := tc.mustRecv(t)
+			if res.Type != FrameEvent {
+				if !want[res.Id] {
+					t.Fatalf("got unexpected reply %+v", res)
+				}
+				delete(want, res.Id)
+				continue
+			}
+
+			var e dto.Event
+			if err := json.Unmarshal(res.Data, &e); err != nil {
+				t.Fatal(err)
+			}
+			if e.Type != string(event.SchoolCreated) {
+				t.Fatalf("got a %s event, the connection only subscribed to %s", e.Type, event.SchoolCreated)
+			}
+			schools = append(schools, e.SchoolId)
+		}
+		return schools
+	}
+
+	tc.send(t, "1", CreateSchool, dto.School{Id: 1})
+	if got := readFrames(1, "1"); got[0] != 1 {
+		t.Fatalf("got events for schools %v, want 1", got)
+	}
+
+	tc.send(t, "p", CreatePerson, nil)
+	tc.send(t, "rolled back", Batch, dto.BatchReq{
+		Atomic: true,
+		Requests: []dto.Request{
+			batchItem(t, "2", CreateSchool, dto.School{Id: 2}),
+			batchItem(t, "3", CreateSchool, dto.School{Id: 3, Name: "fail"}),
+		},
+	})
+	// requests of a connection run concurrently, so the batch below has
+	// to wait for these
+	if got := readFrames(0, "p", "rolled back"); len(got) != 0 {
+		t.Fatalf("got events for schools %v, want none", got)
+	}
+
+	tc.send(t, "committed", Batch, dto.BatchReq{
+		Atomic: true,
+		Requests: []dto.Request{
+			batchItem(t, "4", CreateSchool, dto.School{Id: 4}),
+			batchItem(t, "5", CreateSchool, dto.School{Id: 5}),
+		},
+	})
+	// events are sent in the order they were published, so any that
+	// should have been dropped would show up first
+	if got := readFrames(2, "committed"); got[0] != 4 || got[1] != 5 {
+		t.Fatalf("got events for schools %v, want 4 and 5", got)
+	}
+
+	tc.send(t, "unsub", Unsubscribe, nil)
+	if res := tc.mustRecv(t); res.Id != "unsub" || !res.Status {
+		t.Fatalf("got %+v, want the unsubscribe reply", res)
+	}
+	tc.send(t, "6", CreateSchool, dto.School{Id: 6})
+	if res := tc.mustRecv(t); res.Id != "6" {
+		t.Fatalf("got %+v after unsubscribing, want the reply", res)
+	}
+}
+
+func batchItem(t *testing.T, id string, reqType RequestType, payload any) dto.Request {
+	t.Helper()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dto.Request{Id: id, Type: string(reqType), Payload: data}
+}
@@ -0,0 +1,112 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Framing selects how messages are delimited on the wire.
+type Framing string
+
+const (
+	// NewlineFraming sends one JSON document per line, which keeps the
+	// protocol usable from nc and similar tools.
+	NewlineFraming Framing = "newline"
+	// LengthPrefixedFraming prefixes every message with its size as a
+	// 4-byte big-endian integer, so oversized frames are rejected before
+	// their body is read.
+	LengthPrefixedFraming Framing = "length"
+)
+
+const frameHeaderSize = 4
+
+var ErrFrameTooLarge = errors.New("frame too large")
+
+func (f Framing) validate() error {
+	switch f {
+	case "", NewlineFraming, LengthPrefixedFraming:
+		return nil
+	default:
+		return fmt.Errorf("unknown framing: %q", f)
+	}
+}
+
+type frameReader interface {
+	// ReadFrame returns the next message without its delimiter. A frame
+	// bigger than the reader's limit isn't read any further, it returns
+	// ErrFrameTooLarge and the stream can't be read after it.
+	ReadFrame() ([]byte, error)
+}
+
+// newFrameReader returns a reader for the given framing. A maxSize of
+// zero or less disables the size check.
+func newFrameReader(f Framing, r *bufio.Reader, maxSize int64) frameReader {
+	if f == LengthPrefixedFraming {
+		return &lengthFrameReader{r: r, maxSize: maxSize}
+	}
+	return &newlineFrameReader{r: r, maxSize: maxSize}
+}
+
+type newlineFrameReader struct {
+	r       *bufio.Reader
+	maxSize int64
+}
+
+func (fr *newlineFrameReader) ReadFrame() ([]byte, error) {
+	var line []byte
+	for {
+		// stop at the limit instead of buffering the whole line first
+		part, err := fr.r.ReadSlice('\n')
+		if fr.maxSize > 0 && int64(len(line)+len(part)) > fr.maxSize {
+			return nil, ErrFrameTooLarge
+		}
+		line = append(line, part...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return line[:len(line)-1], nil
+	}
+}
+
+type lengthFrameReader struct {
+	r       *bufio.Reader
+	maxSize int64
+}
+
+func (fr *lengthFrameReader) ReadFrame() ([]byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(fr.r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[:]))
+	if fr.maxSize > 0 && size > fr.maxSize {
+		return nil, ErrFrameTooLarge
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(fr.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// encodeFrame returns data with the delimiter the framing expects.
+func encodeFrame(f Framing, data []byte) []byte {
+	if f == LengthPrefixedFraming {
+		frame := make([]byte, frameHeaderSize+len(data))
+		binary.BigEndian.PutUint32(frame, uint32(len(data)))
+		copy(frame[frameHeaderSize:], data)
+		return frame
+	}
+	return append(data, '\n')
+}
@@ -0,0 +1,156 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+func TestFrameReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		framing Framing
+		maxSize int64
+		frames  []string
+		// a frame or an error per ReadFrame call, nothing is read after
+		// ErrFrameTooLarge
+		want []any
+	}{
+		{
+			name:    "length",
+			framing: LengthPrefixedFraming,
+			maxSize: 5,
+			frames:  []string{"abc", "abcde", "", "abcdef", "abc"},
+			want:    []any{"abc", "abcde", "", ErrFrameTooLarge},
+		},
+		{
+			name:    "length without limit",
+			framing: LengthPrefixedFraming,
+			frames:  []string{strings.Repeat("x", 1000)},
+			want:    []any{strings.Repeat("x", 1000), io.EOF},
+		},
+		{
+			// the limit includes the newline
+			name:    "newline",
+			framing: NewlineFraming,
+			maxSize: 5,
+			frames:  []string{"abc", "abcd", "abcde", "abc"},
+			want:    []any{"abc", "abcd", ErrFrameTooLarge},
+		},
+		{
+			// longer than the buffer of the bufio.Reader
+			name:    "newline longer than the buffer",
+			framing: NewlineFraming,
+			maxSize: 10000,
+			frames:  []string{strings.Repeat("x", 9999), strings.Repeat("x", 10000)},
+			want:    []any{strings.Repeat("x", 9999), ErrFrameTooLarge},
+		},
+		{
+			name:    "newline without limit",
+			framing: NewlineFraming,
+			frames:  []string{strings.Repeat("x", 1000)},
+			want:    []any{strings.Repeat("x", 1000), io.EOF},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stream []byte
+			for _, f := range tt.frames {
+				stream = append(stream, encodeFrame(tt.framing, []byte(f))...)
+			}
+			fr := newFrameReader(tt.framing, bufio.NewReader(bytes.NewReader(stream)), tt.maxSize)
+
+			for i, want := range tt.want {
+				frame, err := fr.ReadFrame()
+				switch want := want.(type) {
+				case error:
+					if !errors.Is(err, want) {
+						t.Fatalf("read %d: got %q, %v, want %v", i, frame, err, want)
+					}
+				case string:
+					if err != nil || string(frame) != want {
+						t.Fatalf("read %d: got %q, %v, want %q", i, frame, err, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLengthFrameReaderTruncated(t *testing.T) {
+	header := func(size uint32) []byte {
+		return binary.BigEndian.AppendUint32(nil, size)
+	}
+
+	tests := []struct {
+		name   string
+		stream []byte
+		want   error
+	}{
+		{"short header", []byte{0, 0}, io.ErrUnexpectedEOF},
+		{"short body", append(header(10), "abc"...), io.ErrUnexpectedEOF},
+		{"no body", header(10), io.ErrUnexpectedEOF},
+		// the body of an oversized frame is never read
+		{"oversized", append(header(1<<31), "abc"...), ErrFrameTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fr := newFrameReader(LengthPrefixedFraming, bufio.NewReader(bytes.NewReader(tt.stream)), 1024)
+			if _, err := fr.ReadFrame(); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// An oversized frame is answered with an error and the connection is
+// closed without reading the rest of it.
+func TestServerRejectsOversizedFrames(t *testing.T) {
+	cfg := testCfg()
+	cfg.MaxMessageSize = 64
+	tc := serveTestConn(t, newTestServer(cfg))
+
+	// more than the server would ever read of it
+	go tc.Write([]byte(`{"id":"1","type":"ping","payload":"` + strings.Repeat("x", 1<<20)))
+	res := tc.mustRecv(t)
+	if res.Code != string(CodeInvalidArgument) || res.Message != "message too large" {
+		t.Fatalf("got %+v, want message too large", res)
+	}
+	if res, err := tc.recv(); err == nil {
+		t.Fatalf("got %+v, want the connection closed", res)
+	}
+}
+
+func TestClientClosesOnOversizedFrames(t *testing.T) {
+	srv := newFakeServer(t)
+	c := NewClient(WithClientCfg(ClientConfig{
+		Network:        "tcp",
+		Address:        srv.ln.Addr().String(),
+		Framing:        NewlineFraming,
+		ReadTimeout:    time.Second,
+		WriteTimeout:   time.Second,
+		ConnectTimeout: time.Second,
+		MaxMessageSize: 64,
+	}))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	srv.write(srv.last(), dto.Response{Type: FrameEvent, Message: strings.Repeat("x", 100)})
+	waitFor(t, "disconnect", func() bool { return !c.IsConnected() })
+	select {
+	case <-srv.eof:
+	case <-time.After(2 * time.Second):
+		t.Fatal("client didn't close the connection")
+	}
+}
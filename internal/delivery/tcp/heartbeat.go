@@ -0,0 +1,216 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+const (
+	// defaultHeartbeatMisses is used when a heartbeat interval is set
+	// without a number of misses.
+	defaultHeartbeatMisses = 3
+	// heartbeatVersion is the first protocol version whose clients answer
+	// ping frames. Older clients are never pinged.
+	heartbeatVersion = 2
+	// heartbeatIdPrefix sets the ids of server pings apart from the ids
+	// clients pick for their requests.
+	heartbeatIdPrefix = "hb-"
+)
+
+// heartbeat tracks the pings sent to a peer that haven't been answered.
+type heartbeat struct {
+	mu       sync.Mutex
+	seq      uint64
+	sentAt   time.Time
+	awaiting bool
+	misses   int
+	latency  time.Duration
+}
+
+// ping records a new ping and returns its sequence number, or false once
+// the peer has missed maxMisses pings in a row.
+func (h *heartbeat) ping(maxMisses int) (uint64, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.awaiting {
+		h.misses++
+	}
+	if h.misses >= maxMisses {
+		return 0, false
+	}
+
+	h.seq++
+	h.sentAt = time.Now()
+	h.awaiting = true
+	return h.seq, true
+}
+
+// pong records the answer to ping seq, stale answers are ignored.
+func (h *heartbeat) pong(seq uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.awaiting || seq != h.seq {
+		return
+	}
+
+	h.latency = time.Since(h.sentAt)
+	h.awaiting = false
+	h.misses = 0
+}
+
+func (h *heartbeat) stats() (time.Duration, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latency, h.misses
+}
+
+func heartbeatMisses(n int) int {
+	if n < 1 {
+		return defaultHeartbeatMisses
+	}
+	return n
+}
+
+// ConnectionInfo describes a connection the server is serving.
+type ConnectionInfo struct {
+	Id         uint64
+	RemoteAddr string
+	// round trip of the last answered heartbeat, zero before the first
+	Latency          time.Duration
+	MissedHeartbeats int
+}
+
+func (s *server) Connections() []ConnectionInfo {
+	var infos []ConnectionInfo
+	s.connections.Range(func(_, v any) bool {
+		c := v.(*connection)
+		latency, misses := c.heartbeat.stats()
+		infos = append(infos, ConnectionInfo{
+			Id:               c.id,
+			RemoteAddr:       c.addr,
+			Latency:          latency,
+			MissedHeartbeats: misses,
+		})
+		return true
+	})
+	return infos
+}
+
+// sendHeartbeats pings the peer every HeartbeatInterval and closes the
+// connection once it misses HeartbeatMisses pings in a row. It is started
+// by hello once the client agreed on a version that answers pings.
+func (s *server) sendHeartbeats(c *connection) {
+	ticker := time.NewTicker(s.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	maxMisses := heartbeatMisses(s.cfg.HeartbeatMisses)
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if c.draining.Load() {
+				return
+			}
+			// the read loop doesn't read pongs while the connection is at
+			// its in-flight limit, a busy peer isn't a dead one
+			if len(c.slots) == cap(c.slots) {
+				continue
+			}
+
+			seq, ok := c.heartbeat.ping(maxMisses)
+			if !ok {
+				s.logger.Printf("Evicting %s after %d missed heartbeats\n", c.addr, maxMisses)
+				c.Close()
+				return
+			}
+
+			s.write(c, dto.Response{
+				Id:   heartbeatIdPrefix + strconv.FormatUint(seq, 10),
+				Type: FramePing,
+			})
+		}
+	}
+}
+
+// handlePing answers a ping request of the client. Pings don't take an
+// in-flight slot, but the read loop stops reading while the connection is
+// at its in-flight limit, so a ping sent then is answered once a request
+// finishes.
+func (s *server) handlePing(c *connection, req dto.Request) {
+	s.write(c, dto.Response{
+		Id:     req.Id,
+		Status: true,
+		Type:   FramePong,
+	})
+}
+
+func (s *server) handlePong(c *connection, req dto.Request) {
+	id, ok := strings.CutPrefix(req.Id, heartbeatIdPrefix)
+	if !ok {
+		return
+	}
+	if seq, err := strconv.ParseUint(id, 10, 64); err == nil {
+		c.heartbeat.pong(seq)
+	}
+}
+
+// Latency returns the round trip of the last answered heartbeat.
+func (c *Client) Latency() time.Duration {
+	c.mu.RLock()
+	hb := c.heartbeat
+	c.mu.RUnlock()
+
+	if hb == nil {
+		return 0
+	}
+	latency, _ := hb.stats()
+	return latency
+}
+
+// sendHeartbeats pings the server every HeartbeatInterval and closes the
+// connection once HeartbeatMisses pings in a row go unanswered.
+func (c *Client) sendHeartbeats(conn net.Conn, hb *heartbeat, done <-chan struct{}) {
+	ticker := time.NewTicker(c.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	maxMisses := heartbeatMisses(c.config.HeartbeatMisses)
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			seq, ok := hb.ping(maxMisses)
+			if !ok {
+				conn.Close()
+				return
+			}
+
+			// a ping is answered by the server without going through a
+			// handler, so anything slower than the interval is a miss
+			ctx, cancel := context.WithTimeout(context.Background(), c.config.HeartbeatInterval)
+			res, err := c.send(ctx, Ping, "", 0, nil)
+			cancel()
+			if err == nil && res.Status {
+				hb.pong(seq)
+			}
+		}
+	}
+}
+
+// answerPing replies to a ping frame of the server.
+func (c *Client) answerPing(id string) {
+	reqBytes, err := c.currentCodec().Marshal(dto.Request{Id: id, Type: string(Pong)})
+	if err != nil {
+		return
+	}
+	c.write(context.Background(), reqBytes)
+}
@@ -0,0 +1,103 @@
+package tcp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+func heartbeatCfg() SrvCfg {
+	cfg := testCfg()
+	cfg.HeartbeatInterval = 10 * time.Millisecond
+	cfg.HeartbeatMisses = 2
+	return cfg
+}
+
+func TestHeartbeatSkipsClientsWithoutHello(t *testing.T) {
+	s := newTestServer(heartbeatCfg())
+	tc := serveTestConn(t, s)
+
+	// long enough to evict a client that was pinged
+	time.Sleep(100 * time.Millisecond)
+
+	tc.send(t, "1", Ping, nil)
+	res := tc.mustRecv(t)
+	if res.Type != FramePong || res.Id != "1" {
+		t.Fatalf("got %+v, want the pong of request 1", res)
+	}
+}
+
+func TestHeartbeatEvictsSilentClients(t *testing.T) {
+	s := newTestServer(heartbeatCfg())
+	tc := serveTestConn(t, s)
+
+	tc.send(t, "hello", Hello, dto.HelloReq{Version: ProtocolVersion})
+	if res := tc.mustRecv(t); !res.Status {
+		t.Fatalf("hello failed: %s", res.Message)
+	}
+
+	var pings int
+	for {
+		res, err := tc.recv()
+		if err != nil {
+			break
+		}
+		if res.Type != FramePing || !strings.HasPrefix(res.Id, heartbeatIdPrefix) {
+			t.Fatalf("got %+v, want a ping", res)
+		}
+		pings++
+	}
+	if pings == 0 {
+		t.Fatal("connection closed without being pinged")
+	}
+}
+
+func TestHeartbeatKeepsAnsweringClients(t *testing.T) {
+	s := newTestServer(heartbeatCfg())
+	tc := serveTestConn(t, s)
+
+	tc.send(t, "hello", Hello, dto.HelloReq{Version: ProtocolVersion})
+	tc.mustRecv(t)
+
+	for i := 0; i < 10; i++ {
+		res := tc.mustRecv(t)
+		if res.Type != FramePing {
+			t.Fatalf("got %+v, want a ping", res)
+		}
+		tc.send(t, res.Id, Pong, nil)
+	}
+
+	conns := s.Connections()
+	if len(conns) != 1 || conns[0].Latency == 0 {
+		t.Fatalf("got %+v, want one connection with a latency", conns)
+	}
+}
+
+func TestHandlePongIgnoresRequestIds(t *testing.T) {
+	s := newTestServer(testCfg())
+	c := &connection{}
+
+	seq, _ := c.heartbeat.ping(defaultHeartbeatMisses)
+
+	tests := []struct {
+		id       string
+		awaiting bool
+	}{
+		{id: "1", awaiting: true},
+		{id: "hb-x", awaiting: true},
+		{id: heartbeatIdPrefix + "0", awaiting: true},
+		{id: heartbeatIdPrefix + "1", awaiting: false},
+	}
+	if seq != 1 {
+		t.Fatalf("first ping got sequence %d", seq)
+	}
+
+	for _, tt := range tests {
+		s.handlePong(c, dto.Request{Id: tt.id, Type: string(Pong)})
+		if c.heartbeat.awaiting != tt.awaiting {
+			t.Errorf("after pong %q awaiting is %v, want %v", tt.id, c.heartbeat.awaiting, tt.awaiting)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package tcp
+
+import (
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+// the most items a page of a list_* request holds, larger lists should
+// be streamed
+const maxPageSize = 1000
+
+// listOptions returns the options asked for in req. Requests without a
+// page size aren't paged and get every item, as they did before lists
+// had pages. Larger page sizes are capped at maxPageSize.
+func listOptions(req dto.ListReq) (repository.ListOptions, error) {
+	if req.PageSize < 0 {
+		return repository.ListOptions{}, NewError(CodeInvalidArgument, "page_size can't be negative")
+	}
+
+	return repository.ListOptions{
+		IncludeDeleted: req.IncludeDeleted,
+		NamePrefix:     req.NamePrefix,
+		Sort:           req.Sort,
+		Limit:          min(req.PageSize, maxPageSize),
+		Cursor:         req.Cursor,
+	}, nil
+}
+
+// streamOptions returns the options asked for in req, streams go through
+// every record so they have no page size or cursor.
+func streamOptions(req dto.StreamReq) repository.ListOptions {
+	return repository.ListOptions{
+		IncludeDeleted: req.IncludeDeleted,
+		NamePrefix:     req.NamePrefix,
+		Sort:           req.Sort,
+	}
+}
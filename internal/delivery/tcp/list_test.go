@@ -0,0 +1,78 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/school"
+)
+
+func TestListOptionsPageSize(t *testing.T) {
+	tests := []struct {
+		pageSize int
+		want     int
+		code     ErrorCode
+	}{
+		{pageSize: 0, want: 0},
+		{pageSize: 1, want: 1},
+		{pageSize: maxPageSize, want: maxPageSize},
+		{pageSize: maxPageSize + 1, want: maxPageSize},
+		{pageSize: -1, code: CodeInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		opts, err := listOptions(dto.ListReq{PageSize: tt.pageSize, Sort: "name", Cursor: "c"})
+		if tt.code != "" {
+			if !HasCode(err, tt.code) {
+				t.Errorf("page size %d: got error %v, want %s", tt.pageSize, err, tt.code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("page size %d: %v", tt.pageSize, err)
+		}
+		if opts.Limit != tt.want || opts.Sort != "name" || opts.Cursor != "c" {
+			t.Errorf("page size %d: got %+v, want limit %d", tt.pageSize, opts, tt.want)
+		}
+	}
+}
+
+func TestListWithoutPageSizeReturnsEverything(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	const n = 150
+	for i := 0; i < n; i++ {
+		if _, err := db.CreateSchool(ctx, fmt.Sprintf("school %03d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := newTestServer(testCfg())
+	s.schoolUsecases = school.NewSchoolUseCases(nil, school.NewListSchoolsUseCase(db), nil, nil, nil)
+	s.RegisterHandler(ListSchools, s.ListSchoolsHandler)
+	tc := serveTestConn(t, s)
+
+	tc.send(t, "all", ListSchools, nil)
+	res := tc.mustRecv(t)
+	var schools []dto.School
+	if err := json.Unmarshal(res.Data, &schools); err != nil {
+		t.Fatalf("got %s, want the bare list: %v", res.Data, err)
+	}
+	if len(schools) != n {
+		t.Fatalf("got %d schools, want all %d", len(schools), n)
+	}
+
+	tc.send(t, "page", ListSchools, dto.ListReq{PageSize: 100})
+	res = tc.mustRecv(t)
+	var page dto.SchoolPage
+	if err := json.Unmarshal(res.Data, &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 100 || page.NextCursor == "" {
+		t.Fatalf("got a page of %d schools with cursor %q, want 100 and a cursor", len(page.Items), page.NextCursor)
+	}
+}
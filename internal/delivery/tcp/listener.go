@@ -0,0 +1,82 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ListenerCfg describes one address the server accepts connections on.
+// All listeners share the handlers and the connection limit of the server.
+type ListenerCfg struct {
+	Network string
+	Address string
+	// TLS is optional, a nil config serves plain TCP
+	TLS *TLSConfig
+	// Unix only applies when Network is unix
+	Unix UnixSocketConfig
+
+	// zero timeouts fall back to the server-wide values in SrvCfg
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+}
+
+type listener struct {
+	net.Listener
+	cfg ListenerCfg
+}
+
+// listenerCfgs returns the configured listeners, or a single one built from
+// Network and Address when none are listed.
+func (cfg *SrvCfg) listenerCfgs() []ListenerCfg {
+	lcfgs := cfg.Listeners
+	if len(lcfgs) == 0 {
+		lcfgs = []ListenerCfg{{
+			Network: cfg.Network,
+			Address: cfg.Address,
+			TLS:     cfg.TLS,
+			Unix:    cfg.Unix,
+		}}
+	}
+
+	resolved := make([]ListenerCfg, 0, len(lcfgs))
+	for _, l := range lcfgs {
+		if l.ReadTimeout == 0 {
+			l.ReadTimeout = cfg.ReadTimeout
+		}
+		if l.WriteTimeout == 0 {
+			l.WriteTimeout = cfg.WriteTimeout
+		}
+		if l.IdleTimeout == 0 {
+			l.IdleTimeout = cfg.IdleTimeout
+		}
+		resolved = append(resolved, l)
+	}
+	return resolved
+}
+
+func listen(cfg ListenerCfg) (*listener, error) {
+	var l net.Listener
+	var err error
+	if isUnixNetwork(cfg.Network) {
+		l, err = listenUnix(cfg.Network, cfg.Address, cfg.Unix)
+	} else {
+		l, err = net.Listen(cfg.Network, cfg.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to start listener on %s: %w", cfg.Address, err)
+	}
+
+	if cfg.TLS != nil {
+		tlsCfg, err := cfg.TLS.serverConfig()
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l = tls.NewListener(l, tlsCfg)
+	}
+
+	return &listener{Listener: l, cfg: cfg}, nil
+}
@@ -0,0 +1,40 @@
+package mapper
+
+import (
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+func ClassToDto(c *entity.Class) *dto.Class {
+	if c == nil {
+		return nil
+	}
+
+	var students []dto.Person
+	for _, s := range c.Students {
+		students = append(students, *PersonToDto(&s))
+	}
+
+	return &dto.Class{
+		Id:       c.Id,
+		Name:     c.Name,
+		SchoolId: c.SchoolId,
+		Teacher:  *PersonToDto(&c.Teacher),
+		Students: students,
+
+		DeletedAt: deletedAt(c.DeletedAt),
+	}
+}
+
+func ClassesToDtos(classes *[]entity.Class) []dto.Class {
+	classDtos := []dto.Class{}
+	if classes == nil {
+		return classDtos
+	}
+
+	for _, c := range *classes {
+		classDtos = append(classDtos, *ClassToDto(&c))
+	}
+
+	return classDtos
+}
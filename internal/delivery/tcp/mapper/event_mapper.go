@@ -0,0 +1,16 @@
+package mapper
+
+import (
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+)
+
+func EventToDto(e event.Event) dto.Event {
+	return dto.Event{
+		Type:       string(e.Type),
+		OccurredAt: e.OccurredAt,
+		SchoolId:   e.SchoolId,
+		PersonId:   e.PersonId,
+		ClassId:    e.ClassId,
+	}
+}
@@ -0,0 +1,40 @@
+package mapper
+
+import (
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+func PersonToDto(p *entity.Person) *dto.Person {
+	if p == nil {
+		return nil
+	}
+
+	person := &dto.Person{
+		Id:       p.Id,
+		Name:     p.Name,
+		Role:     string(p.Role),
+		SchoolId: p.School.Id,
+		Classes:  p.Classes,
+
+		DeletedAt: deletedAt(p.DeletedAt),
+	}
+	if p.School.Id != 0 {
+		person.School = SchoolToDto(&p.School)
+	}
+
+	return person
+}
+
+func PersonsToDtos(persons *[]entity.Person) []dto.Person {
+	personDtos := []dto.Person{}
+	if persons == nil {
+		return personDtos
+	}
+
+	for _, p := range *persons {
+		personDtos = append(personDtos, *PersonToDto(&p))
+	}
+
+	return personDtos
+}
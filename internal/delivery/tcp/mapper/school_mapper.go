@@ -0,0 +1,41 @@
+package mapper
+
+import (
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+func SchoolToDto(s *entity.School) *dto.School {
+	if s == nil {
+		return nil
+	}
+
+	return &dto.School{
+		Id:        s.Id,
+		Name:      s.Name,
+		DeletedAt: deletedAt(s.DeletedAt),
+	}
+}
+
+func SchoolsToDtos(schools *[]entity.School) []dto.School {
+	schoolDtos := []dto.School{}
+	if schools == nil {
+		return schoolDtos
+	}
+
+	for _, s := range *schools {
+		schoolDtos = append(schoolDtos, *SchoolToDto(&s))
+	}
+
+	return schoolDtos
+}
+
+// deletedAt leaves the deletion time out of records that weren't deleted.
+func deletedAt(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
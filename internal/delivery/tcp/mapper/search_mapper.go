@@ -0,0 +1,19 @@
+package mapper
+
+import (
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+func SearchResultsToDtos(results *[]entity.SearchResult) []dto.SearchResult {
+	dtos := make([]dto.SearchResult, 0, len(*results))
+	for _, r := range *results {
+		dtos = append(dtos, dto.SearchResult{
+			Kind: string(r.Kind),
+			Id:   r.Id,
+			Name: r.Name,
+			Rank: r.Rank,
+		})
+	}
+	return dtos
+}
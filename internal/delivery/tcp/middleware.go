@@ -0,0 +1,101 @@
+package tcp
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a RequestHandler to run code around it. Details of the
+// request being handled are available through RequestInfoFromContext.
+// Panics in middlewares and handlers are recovered and counted by the
+// server, see Panics.
+type Middleware func(RequestHandler) RequestHandler
+
+func (s *server) Use(mws ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middlewares = append(s.middlewares, mws...)
+	for reqType, handler := range s.handlers {
+		s.chained[reqType] = s.chain(reqType, handler)
+	}
+}
+
+func (s *server) UseFor(reqType RequestType, mws ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.typeMiddlewares[reqType] = append(s.typeMiddlewares[reqType], mws...)
+	if handler, ok := s.handlers[reqType]; ok {
+		s.chained[reqType] = s.chain(reqType, handler)
+	}
+}
+
+// chain wraps handler with the middlewares of reqType, then with the
+// global ones, so global middlewares run first. The caller holds s.mu.
+func (s *server) chain(reqType RequestType, handler RequestHandler) RequestHandler {
+	mws := s.typeMiddlewares[reqType]
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recovery turns a panic in the wrapped handler into an internal error
+// and logs it with its stack trace. The server recovers panics anyway,
+// Recovery is for the middlewares around it: they see the failed request
+// instead of being unwound, so an AccessLog used before it logs it too.
+// Panics it recovers aren't counted by Panics.
+func Recovery(logger *log.Logger) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, payload Payload) (result interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					info, _ := RequestInfoFromContext(ctx)
+					logger.Printf("Panic in %s handler, request %s from %s: %v\n%s",
+						info.Type, info.Id, info.RemoteAddr, r, debug.Stack())
+					result, err = nil, NewError(CodeInternal, "internal error")
+				}
+			}()
+			return next(ctx, payload)
+		}
+	}
+}
+
+// AccessLog logs every request with its outcome and duration.
+func AccessLog(logger *log.Logger) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, payload Payload) (interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, payload)
+
+			info, _ := RequestInfoFromContext(ctx)
+			status := "ok"
+			if err != nil {
+				status = "error: " + err.Error()
+			}
+			logger.Printf("%s %s id=%s %s (%s)\n", info.RemoteAddr, info.Type, info.Id, status, time.Since(start))
+
+			return result, err
+		}
+	}
+}
+
+// Timing reports how long every request took to observe, e.g. to feed
+// metrics or flag slow requests.
+func Timing(observe func(reqType RequestType, elapsed time.Duration, err error)) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, payload Payload) (interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, payload)
+
+			info, _ := RequestInfoFromContext(ctx)
+			observe(info.Type, time.Since(start), err)
+
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tcp
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/codec"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+// record returns a middleware that appends name to calls when it runs and
+// counts how often it wraps a handler in wraps.
+func record(name string, calls *[]string, wraps *int) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		*wraps++
+		return func(ctx context.Context, payload Payload) (interface{}, error) {
+			*calls = append(*calls, name)
+			return next(ctx, payload)
+		}
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	s := newTestServer(testCfg())
+	c := &connection{codec: codec.Default()}
+
+	var calls []string
+	var wraps int
+	s.Use(record("global", &calls, &wraps))
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		calls = append(calls, "handler")
+		return nil, nil
+	})
+	// middlewares added after the handler apply to it too
+	s.UseFor(ListSchools, record("type", &calls, &wraps))
+	s.UseFor(ListClasses, record("other type", &calls, &wraps))
+
+	wrapped := wraps
+	for i := 0; i < 3; i++ {
+		calls = nil
+		if res := s.execute(context.Background(), c, dto.Request{Id: "1", Type: string(ListSchools)}); !res.Status {
+			t.Fatalf("request failed: %s", res.Message)
+		}
+		if want := []string{"global", "type", "handler"}; !slices.Equal(calls, want) {
+			t.Fatalf("got calls %v, want %v", calls, want)
+		}
+	}
+	if wraps != wrapped {
+		t.Fatalf("handler wrapped %d more times by requests, want once when registered", wraps-wrapped)
+	}
+}
+
+func TestPanicsAreRecovered(t *testing.T) {
+	s := newTestServer(testCfg())
+	c := &connection{codec: codec.Default()}
+
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		panic("handler")
+	})
+	s.RegisterHandler(ListClasses, func(ctx context.Context, payload Payload) (interface{}, error) {
+		return nil, nil
+	})
+	s.UseFor(ListClasses, func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, payload Payload) (interface{}, error) {
+			panic("middleware")
+		}
+	})
+
+	for i, reqType := range []RequestType{ListSchools, ListClasses} {
+		res := s.execute(context.Background(), c, dto.Request{Id: "1", Type: string(reqType)})
+		if res.Status || res.Code != string(CodeInternal) {
+			t.Fatalf("%s: got %+v, want an internal error", reqType, res)
+		}
+		if got := s.Panics(); got != uint64(i+1) {
+			t.Fatalf("%s: counted %d panics, want %d", reqType, got, i+1)
+		}
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	s := newTestServer(testCfg())
+	c := &connection{codec: codec.Default()}
+
+	var logged bytes.Buffer
+	logger := log.New(&logged, "", 0)
+	s.Use(AccessLog(logger), Recovery(logger))
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		panic("handler")
+	})
+
+	res := s.execute(context.Background(), c, dto.Request{Id: "1", Type: string(ListSchools)})
+	if res.Status || res.Code != string(CodeInternal) {
+		t.Fatalf("got %+v, want an internal error", res)
+	}
+	if got := s.Panics(); got != 0 {
+		t.Fatalf("server counted %d panics, want Recovery to catch it first", got)
+	}
+
+	out := logged.String()
+	for _, want := range []string{
+		"Panic in list_schools handler, request 1",
+		"runtime/debug.Stack",
+		// the access log around Recovery saw the request fail
+		"list_schools id=1 error: internal error",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log is missing %q:\n%s", want, out)
+		}
+	}
+}
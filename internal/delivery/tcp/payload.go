@@ -0,0 +1,30 @@
+package tcp
+
+import "github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/codec"
+
+// Payload is the body of a request, still encoded with the codec that was
+// negotiated for the connection it arrived on.
+type Payload struct {
+	data  []byte
+	codec codec.Codec
+}
+
+func (p Payload) Decode(v interface{}) error {
+	if err := p.codec.Unmarshal(p.data, v); err != nil {
+		return NewError(CodeInvalidArgument, "invalid payload")
+	}
+	return nil
+}
+
+// decodeOptional is Decode for requests that didn't take a payload
+// before. The empty string older clients send leaves v unchanged.
+func (p Payload) decodeOptional(v interface{}) error {
+	if err := p.codec.Unmarshal(p.data, v); err != nil {
+		var s string
+		if p.codec.Unmarshal(p.data, &s) == nil && s == "" {
+			return nil
+		}
+		return NewError(CodeInvalidArgument, "invalid payload")
+	}
+	return nil
+}
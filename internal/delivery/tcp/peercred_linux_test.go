@@ -0,0 +1,71 @@
+//go:build linux
+
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUnixPeerCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+	}{
+		{"socket file", filepath.Join(t.TempDir(), "server.sock")},
+		// abstract sockets are shared by the whole network namespace
+		{"abstract", fmt.Sprintf("@school-test-%d-%d", os.Getpid(), time.Now().UnixNano())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testCfg()
+			cfg.Network, cfg.Address = "unix", tt.address
+			s := newTestServer(cfg)
+			s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+				cred, ok := PeerCreds(ctx)
+				if !ok {
+					return nil, NewError(CodeInternal, "no peer credentials")
+				}
+				return cred, nil
+			})
+			addr := startTestServer(t, s)
+			if addr != tt.address {
+				t.Fatalf("listening on %q, want %q", addr, tt.address)
+			}
+
+			c := NewClient(WithClientCfg(ClientConfig{
+				Network:        "unix",
+				Address:        addr,
+				Framing:        NewlineFraming,
+				ReadTimeout:    time.Second,
+				WriteTimeout:   time.Second,
+				ConnectTimeout: time.Second,
+			}))
+			if err := c.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			res, err := c.Send(context.Background(), ListSchools, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ResponseError(res); err != nil {
+				t.Fatal(err)
+			}
+			var got PeerCredentials
+			if err := c.Decode(res, &got); err != nil {
+				t.Fatal(err)
+			}
+			want := PeerCredentials{Pid: int32(os.Getpid()), Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())}
+			if got != want {
+				t.Fatalf("handler saw %+v, want %+v", got, want)
+			}
+		})
+	}
+}
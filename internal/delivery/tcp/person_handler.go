@@ -2,52 +2,158 @@ package tcp
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/mapper"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 )
 
-func (s *server) CreatePersonHandler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+func (s *server) CreatePersonHandler(ctx context.Context, payload Payload) (interface{}, error) {
 	var req dto.CreatePersonReq
 
-	err := json.Unmarshal(payload, &req)
+	err := payload.Decode(&req)
 	if err != nil {
 		return nil, err
 	}
 
 	personUsecases := s.personUsecases
-	personId := personUsecases.CreateUseCase.Execute(entity.Person{
+	personId, err := personUsecases.CreateUseCase.Execute(ctx, entity.Person{
 		Name:   req.Name,
 		Role:   entity.Role(req.Role),
 		School: entity.School{Id: req.SchoolId},
-	})
+	}, req.Password)
+	if err != nil {
+		return nil, err
+	}
 
 	return personId, nil
 }
 
-func (s *server) ListPersonsHandler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+func (s *server) ListPersonsHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.ListPersonsReq
+
+	err := payload.decodeOptional(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts, err := listOptions(dto.ListReq{
+		IncludeDeleted: req.IncludeDeleted,
+		PageSize:       req.PageSize,
+		Cursor:         req.Cursor,
+		Sort:           req.Sort,
+		NamePrefix:     req.NamePrefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	opts := repository.PersonListOptions{
+		ListOptions: listOpts,
+		Role:        entity.Role(req.Role),
+		SchoolId:    req.SchoolId,
+	}
+
+	personUsecases := s.personUsecases
+	persons, next, err := personUsecases.ListUseCase.Execute(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if req.PageSize == 0 {
+		return mapper.PersonsToDtos(persons), nil
+	}
+	return dto.PersonPage{Items: mapper.PersonsToDtos(persons), NextCursor: next}, nil
+}
+
+func (s *server) StreamPersonsHandler(ctx context.Context, payload Payload, send func(chunk interface{}) error) error {
+	var req dto.StreamPersonsReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	streamReq := dto.StreamReq{
+		ChunkSize:      req.ChunkSize,
+		IncludeDeleted: req.IncludeDeleted,
+		Sort:           req.Sort,
+		NamePrefix:     req.NamePrefix,
+	}
+	opts := repository.PersonListOptions{
+		ListOptions: streamOptions(streamReq),
+		Role:        entity.Role(req.Role),
+		SchoolId:    req.SchoolId,
+	}
+
+	personUsecases := s.personUsecases
+	return personUsecases.ListUseCase.ExecuteInBatches(ctx, opts, chunkSize(streamReq), func(persons *[]entity.Person) error {
+		return send(mapper.PersonsToDtos(persons))
+	})
+}
+
+// WhoAmIHandler answers with the person the connection is logged in as.
+func (s *server) WhoAmIHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	identity, ok := SessionIdentity(ctx)
+	if !ok {
+		return nil, NewError(CodeUnauthenticated, "not logged in")
+	}
+
+	personUsecases := s.personUsecases
+	person, err := personUsecases.WhoAmIUseCase.Execute(ctx, identity.PersonId)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.PersonToDto(person), nil
+}
+
+func (s *server) UpdatePersonHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.UpdatePersonReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	personUsecases := s.personUsecases
+	person, err := personUsecases.UpdateUseCase.Execute(ctx, req.Id, req.Name, req.SchoolId)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.PersonToDto(person), nil
+}
+
+func (s *server) DeletePersonHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.IdReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
 	personUsecases := s.personUsecases
-	persons, err := personUsecases.ListUseCase.Execute()
+	err = personUsecases.DeleteUseCase.Execute(ctx, req.Id)
 	if err != nil {
 		return nil, err
 	}
-	return persons, nil
+
+	return "person deleted successfully", nil
 }
 
-func (s *server) WhoAmIHandler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
-	var req dto.WhoAmIReq
+func (s *server) RestorePersonHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	var req dto.IdReq
 
-	err := json.Unmarshal(payload, &req)
+	err := payload.Decode(&req)
 	if err != nil {
 		return nil, err
 	}
 
 	personUsecases := s.personUsecases
-	person, err := personUsecases.WhoAmIUseCase.Execute(req.PersonId)
+	err = personUsecases.RestoreUseCase.Execute(ctx, req.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	return person, nil
+	return "person restored successfully", nil
 }
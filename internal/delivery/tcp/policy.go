@@ -0,0 +1,74 @@
+package tcp
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+// Policy lists the roles that may send each request type. Admins may send
+// anything, other roles only the types that list them. Use cases narrow
+// it down further, e.g. teachers can only add students to their own
+// classes.
+type Policy map[RequestType][]entity.Role
+
+// DefaultPolicy lets students read and search schools, classes and their
+// own record, and teachers also read persons and manage their classes. Updating,
+// deleting and restoring schools and persons, restoring classes and
+// purging are left to admins.
+func DefaultPolicy() Policy {
+	everyone := []entity.Role{entity.StudentRole, entity.TeacherRole}
+	teachers := []entity.Role{entity.TeacherRole}
+
+	return Policy{
+		ListSchools:       everyone,
+		StreamSchools:     everyone,
+		ListClasses:       everyone,
+		StreamClasses:     everyone,
+		WhoAmI:            everyone,
+		ChangePassword:    everyone,
+		Subscribe:         everyone,
+		Unsubscribe:       everyone,
+		Search:            everyone,
+		ListPersons:       teachers,
+		StreamPersons:     teachers,
+		CreateClass:       teachers,
+		AddStudentToClass: teachers,
+		UpdateClass:       teachers,
+		DeleteClass:       teachers,
+	}
+}
+
+// With returns a copy of p with the roles of the given types replaced.
+func (p Policy) With(overrides Policy) Policy {
+	merged := maps.Clone(p)
+	maps.Copy(merged, overrides)
+	return merged
+}
+
+func (p Policy) allows(role entity.Role, reqType RequestType) bool {
+	switch {
+	case role == entity.AdminRole:
+		return true
+	case reqType == Login || reqType == Logout:
+		return true
+	}
+	return slices.Contains(p[reqType], role)
+}
+
+// authorize rejects reqType if the connection has to log in first or
+// its role may not send it.
+func (s *server) authorize(c *connection, reqType RequestType) *Error {
+	identity, ok := c.session.get()
+	if !ok {
+		return s.authenticate(reqType)
+	}
+
+	if !s.policy.allows(identity.Role, reqType) {
+		return NewError(CodePermissionDenied,
+			fmt.Sprintf("%s may not send %s", identity.Role, reqType))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package tcp
+
+import (
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+func TestAuthorize(t *testing.T) {
+	admin := &Identity{PersonId: 1, Role: entity.AdminRole}
+	teacher := &Identity{PersonId: 2, Role: entity.TeacherRole}
+	student := &Identity{PersonId: 3, Role: entity.StudentRole}
+
+	tests := []struct {
+		name     string
+		auth     AuthCfg
+		identity *Identity
+		reqType  RequestType
+		want     ErrorCode
+	}{
+		{"anonymous login", AuthCfg{}, nil, Login, ""},
+		{"anonymous read", AuthCfg{}, nil, ListSchools, ""},
+		{"anonymous reset password", AuthCfg{}, nil, ResetPassword, CodeUnauthenticated},
+		{"anonymous create admin", AuthCfg{}, nil, CreatePerson, CodeUnauthenticated},
+		{"anonymous purge", AuthCfg{}, nil, Purge, CodeUnauthenticated},
+		{"anonymous delete", AuthCfg{}, nil, DeletePerson, CodeUnauthenticated},
+		{"anonymous teacher type", AuthCfg{}, nil, ListPersons, CodeUnauthenticated},
+		{"required read", AuthCfg{Required: true}, nil, ListSchools, CodeUnauthenticated},
+		{"required login", AuthCfg{Required: true}, nil, Login, ""},
+		{"required allowed", AuthCfg{Required: true, Allow: []RequestType{ListSchools}}, nil, ListSchools, ""},
+		{"allowed beyond policy", AuthCfg{Allow: []RequestType{CreateSchool}}, nil, CreateSchool, ""},
+		{"student read", AuthCfg{}, student, ListClasses, ""},
+		{"student teacher type", AuthCfg{}, student, ListPersons, CodePermissionDenied},
+		{"teacher teacher type", AuthCfg{}, teacher, ListPersons, ""},
+		{"teacher admin type", AuthCfg{}, teacher, ResetPassword, CodePermissionDenied},
+		{"admin anything", AuthCfg{}, admin, Purge, ""},
+		{"policy override", AuthCfg{Policy: Policy{ListPersons: {entity.StudentRole}}}, student, ListPersons, ""},
+		{"policy override anonymous", AuthCfg{Policy: Policy{ListPersons: {entity.StudentRole}}}, nil, ListPersons, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(WithCfg(SrvCfg{Auth: tt.auth})).(*server)
+			c := &connection{}
+			c.session.set(tt.identity)
+
+			err := s.authorize(c, tt.reqType)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Fatalf("authorize(%s) = %v, want nil", tt.reqType, err)
+			case tt.want != "" && (err == nil || err.Code != tt.want):
+				t.Fatalf("authorize(%s) = %v, want %s", tt.reqType, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestActor(t *testing.T) {
+	if got := actor(Identity{}, false); got.Role != anonymousRole || got.PersonId != 0 {
+		t.Fatalf("anonymous actor = %+v", got)
+	}
+	got := actor(Identity{PersonId: 7, Role: entity.AdminRole}, true)
+	if got.Role != entity.AdminRole || got.PersonId != 7 {
+		t.Fatalf("actor = %+v", got)
+	}
+}
@@ -0,0 +1,185 @@
+package tcp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/codec"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+const (
+	// ProtocolVersion is the version spoken by this package.
+	ProtocolVersion = 2
+	// MinProtocolVersion is the oldest version still served. Version 1 is
+	// the protocol of clients that don't send hello.
+	MinProtocolVersion = 1
+)
+
+type rename struct {
+	to    RequestType
+	since int
+}
+
+// renamedRequestTypes maps old request type names to their replacement
+// and the protocol version that renamed them. Connections on an older
+// version keep using the old names.
+var renamedRequestTypes = map[RequestType]rename{
+	"creat_school": {CreateSchool, 2},
+	"creat_person": {CreatePerson, 2},
+	"creat_class":  {CreateClass, 2},
+}
+
+// resolveRequestType returns the current name of t as sent on a
+// connection speaking version.
+func resolveRequestType(t RequestType, version int) (RequestType, error) {
+	r, ok := renamedRequestTypes[t]
+	if !ok {
+		return t, nil
+	}
+
+	if version >= r.since {
+		return "", NewError(CodeUnimplemented, fmt.Sprintf(
+			"request type %s was renamed to %s in protocol version %d", t, r.to, r.since))
+	}
+	return r.to, nil
+}
+
+// legacyRequestType returns the name t had in version.
+func legacyRequestType(t RequestType, version int) RequestType {
+	for old, r := range renamedRequestTypes {
+		if r.to == t && version < r.since {
+			return old
+		}
+	}
+	return t
+}
+
+// builtinRequestTypes are handled by the server itself.
+var builtinRequestTypes = []RequestType{Hello, SetCodec, Subscribe, Unsubscribe, Ping, Pong, Batch}
+
+// hello negotiates the protocol version, codec and compression of the
+// connection. Like set_codec it must come before any other request, the
+// reply is sent with the old settings and everything after uses the new.
+func (s *server) hello(c *connection, req dto.Request) {
+	if c.greeted {
+		s.write(c, errorResponse(req.Id, NewError(CodeFailedPrecondition,
+			"hello can only be sent once")))
+		return
+	}
+	if c.dispatched > 0 {
+		s.write(c, errorResponse(req.Id, NewError(CodeFailedPrecondition,
+			"hello must be sent before any other request")))
+		return
+	}
+
+	var body dto.HelloReq
+	if err := c.codec.Unmarshal(req.Payload, &body); err != nil {
+		s.write(c, errorResponse(req.Id, NewError(CodeInvalidArgument, "invalid payload")))
+		return
+	}
+
+	if body.Version < MinProtocolVersion || body.Version > ProtocolVersion {
+		err := NewError(CodeFailedPrecondition, fmt.Sprintf(
+			"unsupported protocol version %d, the server speaks versions %d to %d",
+			body.Version, MinProtocolVersion, ProtocolVersion)).
+			WithDetail("min_version", strconv.Itoa(MinProtocolVersion)).
+			WithDetail("max_version", strconv.Itoa(ProtocolVersion))
+		s.write(c, errorResponse(req.Id, err))
+		return
+	}
+
+	cd := c.codec
+	if len(body.Codecs) > 0 {
+		cd = nil
+		for _, name := range body.Codecs {
+			candidate, err := codec.Get(name)
+			if err != nil || (candidate.Binary() && s.cfg.Framing != LengthPrefixedFraming) {
+				continue
+			}
+			cd = candidate
+			break
+		}
+		if cd == nil {
+			s.write(c, errorResponse(req.Id, NewError(CodeFailedPrecondition,
+				fmt.Sprintf("none of the codecs %v can be used with %s framing", body.Codecs, s.cfg.Framing))))
+			return
+		}
+	}
+
+	compression := NoCompression
+	if len(body.Compressions) > 0 {
+		compression = ""
+		for _, name := range body.Compressions {
+			candidate := Compression(name)
+			if !candidate.supported() ||
+				(candidate != NoCompression && s.cfg.Framing != LengthPrefixedFraming) {
+				continue
+			}
+			compression = candidate
+			break
+		}
+		if compression == "" {
+			s.write(c, errorResponse(req.Id, NewError(CodeFailedPrecondition,
+				fmt.Sprintf("none of the compressions %v can be used with %s framing", body.Compressions, s.cfg.Framing))))
+			return
+		}
+	}
+
+	data, err := c.codec.Marshal(dto.HelloRes{
+		Version:      body.Version,
+		Codec:        cd.Name(),
+		Compression:  string(compression),
+		RequestTypes: s.supportedRequestTypes(body.RequestTypes),
+	})
+	if err != nil {
+		s.write(c, errorResponse(req.Id, NewError(CodeInternal, "failed to encode response")))
+		return
+	}
+
+	s.write(c, dto.Response{
+		Id:     req.Id,
+		Status: true,
+		Data:   data,
+	})
+
+	c.greeted = true
+	c.version = body.Version
+	c.switchTo(cd, compression)
+
+	if s.cfg.HeartbeatInterval > 0 && c.version >= heartbeatVersion {
+		go s.sendHeartbeats(c)
+	}
+}
+
+// supportedRequestTypes returns the types of wanted the server handles,
+// or every type it handles when wanted is empty.
+func (s *server) supportedRequestTypes(wanted []string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	supported := make(map[RequestType]bool)
+	for t := range s.handlers {
+		supported[t] = true
+	}
+	for _, t := range builtinRequestTypes {
+		supported[t] = true
+	}
+
+	types := []string{}
+	if len(wanted) == 0 {
+		for t := range supported {
+			types = append(types, string(t))
+		}
+		sort.Strings(types)
+		return types
+	}
+
+	for _, t := range wanted {
+		if supported[RequestType(t)] {
+			types = append(types, t)
+		}
+	}
+	return types
+}
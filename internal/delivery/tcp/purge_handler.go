@@ -0,0 +1,26 @@
+package tcp
+
+import (
+	"context"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+// PurgeHandler permanently removes old soft deleted records and answers
+// with how many of each were removed.
+func (s *server) PurgeHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	if s.purgeUseCase == nil {
+		return nil, NewError(CodeUnimplemented, "purge is not enabled")
+	}
+
+	purged, err := s.purgeUseCase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.PurgeRes{
+		Schools: purged.Schools,
+		Persons: purged.Persons,
+		Classes: purged.Classes,
+	}, nil
+}
@@ -0,0 +1,193 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit allows Rate requests per second on average, in bursts of up
+// to Burst. A zero Rate means no limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits holds the limit of every scope a request is counted in.
+type RateLimits struct {
+	PerConnection RateLimit
+	PerIP         RateLimit
+	// PerIdentity only applies to clients that logged in or presented
+	// a TLS client certificate or unix peer credentials
+	PerIdentity RateLimit
+}
+
+type RateLimitCfg struct {
+	RateLimits
+	// Types gives request types buckets of their own with these limits,
+	// their requests still count against the ones above as well
+	Types map[RequestType]RateLimits
+}
+
+// how often buckets that are full again are dropped
+const bucketSweepInterval = time.Minute
+
+type bucketKey struct {
+	scope   string
+	id      string
+	reqType RequestType
+}
+
+type bucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per scope and client, and another one
+// per request type for the types with limits of their own.
+type rateLimiter struct {
+	cfg RateLimitCfg
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(cfg RateLimitCfg) *rateLimiter {
+	return &rateLimiter{
+		cfg:       cfg,
+		buckets:   make(map[bucketKey]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// allow takes a token from every bucket the request is counted in, or
+// from none of them if one is empty. In that case it returns how long
+// the client should wait before retrying.
+func (l *rateLimiter) allow(c *connection, identity string, reqType RequestType) (time.Duration, bool) {
+	type counted struct {
+		key   bucketKey
+		limit RateLimit
+	}
+	var scopes []counted
+	count := func(limits RateLimits, reqType RequestType) {
+		scopes = append(scopes,
+			counted{bucketKey{"connection", strconv.FormatUint(c.id, 10), reqType}, limits.PerConnection},
+			counted{bucketKey{"ip", remoteIP(c.addr), reqType}, limits.PerIP},
+		)
+		if identity != "" {
+			scopes = append(scopes, counted{bucketKey{"identity", identity, reqType}, limits.PerIdentity})
+		}
+	}
+
+	count(l.cfg.RateLimits, "")
+	if limits, ok := l.cfg.Types[reqType]; ok {
+		count(limits, reqType)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	var wait time.Duration
+	var taken []*bucket
+	for _, scope := range scopes {
+		if scope.limit.Rate <= 0 {
+			continue
+		}
+
+		b := l.bucket(scope.key, scope.limit, now)
+		if b.tokens < 1 {
+			if w := time.Duration((1 - b.tokens) / scope.limit.Rate * float64(time.Second)); w > wait {
+				wait = w
+			}
+			continue
+		}
+		taken = append(taken, b)
+	}
+
+	if wait > 0 {
+		return wait, false
+	}
+	for _, b := range taken {
+		b.tokens--
+	}
+	return 0, true
+}
+
+// bucket returns the bucket of key with the tokens earned since it was
+// last used added.
+func (l *rateLimiter) bucket(key bucketKey, limit RateLimit, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limit: limit, tokens: limit.burst(), last: now}
+		l.buckets[key] = b
+		return b
+	}
+
+	b.tokens = b.tokensAt(now)
+	b.last = now
+	return b
+}
+
+func (b *bucket) tokensAt(now time.Time) float64 {
+	return min(b.limit.burst(), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+}
+
+func (r RateLimit) burst() float64 {
+	return float64(max(r.Burst, 1))
+}
+
+// sweep drops the buckets that are full again, they behave like new
+// ones. This also forgets closed connections.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokensAt(now) >= b.limit.burst() {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// remoteIP returns the host of addr, or addr itself when it has no port
+// like the addresses of unix socket clients.
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// identity returns who the client authenticated as, or an empty string
+// for anonymous clients.
+func identity(ctx context.Context) string {
+	if id, ok := SessionIdentity(ctx); ok {
+		return fmt.Sprintf("person:%d", id.PersonId)
+	}
+	if subject, ok := ClientCertSubject(ctx); ok && subject.CommonName != "" {
+		return "cert:" + subject.CommonName
+	}
+	if cred, ok := PeerCreds(ctx); ok {
+		return fmt.Sprintf("uid:%d", cred.Uid)
+	}
+	return ""
+}
+
+// rateLimited returns the error sent for a throttled request, the
+// retry_after detail is a duration like "1.5s".
+func rateLimited(wait time.Duration) *Error {
+	wait = (wait + time.Millisecond - 1).Truncate(time.Millisecond)
+	return NewError(CodeRateLimited, "rate limit exceeded").
+		WithDetail("retry_after", wait.String())
+}
@@ -0,0 +1,211 @@
+package tcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+func TestRateLimiterBucket(t *testing.T) {
+	l := newRateLimiter(RateLimitCfg{
+		RateLimits: RateLimits{PerConnection: RateLimit{Rate: 10, Burst: 3}},
+	})
+	c := &connection{id: 1, addr: "10.0.0.1:5000"}
+
+	for i := 0; i < 3; i++ {
+		if _, ok := l.allow(c, "", ListSchools); !ok {
+			t.Fatalf("request %d of the burst was refused", i+1)
+		}
+	}
+
+	wait, ok := l.allow(c, "", ListSchools)
+	if ok {
+		t.Fatal("request after the burst was allowed")
+	}
+	if wait <= 0 || wait > 100*time.Millisecond {
+		t.Fatalf("got wait %v, want at most one token at 10/s", wait)
+	}
+
+	time.Sleep(wait)
+	if _, ok := l.allow(c, "", ListSchools); !ok {
+		t.Fatal("request after waiting was refused")
+	}
+}
+
+func TestRateLimiterScopes(t *testing.T) {
+	one := RateLimit{Rate: 1, Burst: 1}
+	a := &connection{id: 1, addr: "10.0.0.1:5000"}
+	b := &connection{id: 2, addr: "10.0.0.1:5001"}
+	other := &connection{id: 3, addr: "10.0.0.2:5000"}
+
+	type call struct {
+		c        *connection
+		identity string
+		reqType  RequestType
+		allowed  bool
+	}
+	tests := []struct {
+		name  string
+		cfg   RateLimitCfg
+		calls []call
+	}{
+		{
+			name: "connection",
+			cfg:  RateLimitCfg{RateLimits: RateLimits{PerConnection: one}},
+			calls: []call{
+				{a, "", ListSchools, true},
+				{a, "", ListSchools, false},
+				{b, "", ListSchools, true},
+			},
+		},
+		{
+			name: "ip",
+			cfg:  RateLimitCfg{RateLimits: RateLimits{PerIP: one}},
+			calls: []call{
+				{a, "", ListSchools, true},
+				{b, "", ListSchools, false},
+				{other, "", ListSchools, true},
+			},
+		},
+		{
+			name: "identity",
+			cfg:  RateLimitCfg{RateLimits: RateLimits{PerIdentity: one}},
+			calls: []call{
+				{a, "person:1", ListSchools, true},
+				{other, "person:1", ListSchools, false},
+				{other, "person:2", ListSchools, true},
+				// anonymous clients have no identity bucket
+				{a, "", ListSchools, true},
+				{a, "", ListSchools, true},
+			},
+		},
+		{
+			name: "type",
+			cfg: RateLimitCfg{
+				RateLimits: RateLimits{PerConnection: RateLimit{Rate: 1, Burst: 2}},
+				Types:      map[RequestType]RateLimits{Login: {PerConnection: one}},
+			},
+			calls: []call{
+				{a, "", Login, true},
+				// the login bucket is empty, the shared one isn't
+				{a, "", Login, false},
+				{a, "", ListSchools, true},
+				// logins also took a token from the shared bucket
+				{a, "", ListClasses, false},
+				{b, "", Login, true},
+			},
+		},
+		{
+			name: "type keeps the ip limit",
+			cfg: RateLimitCfg{
+				RateLimits: RateLimits{PerIP: one},
+				Types:      map[RequestType]RateLimits{CreatePerson: {PerConnection: RateLimit{Rate: 5, Burst: 10}}},
+			},
+			calls: []call{
+				{a, "", CreatePerson, true},
+				// a second connection doesn't get around the ip bucket
+				{b, "", CreatePerson, false},
+				{other, "", CreatePerson, true},
+			},
+		},
+		{
+			name: "every bucket needs a token",
+			cfg:  RateLimitCfg{RateLimits: RateLimits{PerConnection: RateLimit{Rate: 1, Burst: 2}, PerIP: one}},
+			calls: []call{
+				{a, "", ListSchools, true},
+				{b, "", ListSchools, false},
+				{a, "", ListSchools, false},
+				{b, "", ListSchools, false},
+				{other, "", ListSchools, true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(tt.cfg)
+			for i, call := range tt.calls {
+				if _, ok := l.allow(call.c, call.identity, call.reqType); ok != call.allowed {
+					t.Fatalf("call %d (%s from %s as %q): allowed %v, want %v",
+						i, call.reqType, call.c.addr, call.identity, ok, call.allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitedRetryAfter(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want string
+	}{
+		{time.Nanosecond, "1ms"},
+		{time.Millisecond, "1ms"},
+		{time.Millisecond + time.Microsecond, "2ms"},
+		{100 * time.Millisecond, "100ms"},
+		{1500 * time.Millisecond, "1.5s"},
+	}
+
+	for _, tt := range tests {
+		err := rateLimited(tt.wait)
+		if got := err.Details["retry_after"]; got != tt.want {
+			t.Errorf("rateLimited(%v) retry_after = %q, want %q", tt.wait, got, tt.want)
+		}
+		wait, ok := RetryAfter(err)
+		if want, _ := time.ParseDuration(tt.want); !ok || wait != want {
+			t.Errorf("RetryAfter(rateLimited(%v)) = %v, %v", tt.wait, wait, ok)
+		}
+	}
+}
+
+// The read loop answers these itself, they still count against the
+// buckets of the connection.
+func TestRateLimitBuiltinRequestTypes(t *testing.T) {
+	payloads := map[RequestType]any{
+		Hello:    dto.HelloReq{Version: ProtocolVersion},
+		SetCodec: dto.SetCodecReq{Name: "json"},
+	}
+
+	for _, reqType := range []RequestType{Ping, Pong, Hello, SetCodec, Subscribe, Unsubscribe} {
+		t.Run(string(reqType), func(t *testing.T) {
+			cfg := testCfg()
+			cfg.RateLimit.PerConnection = RateLimit{Rate: 0.01, Burst: 1}
+			tc := serveTestConn(t, newTestServer(cfg))
+
+			tc.send(t, "1", reqType, payloads[reqType])
+			if reqType != Pong {
+				if res := tc.mustRecv(t); res.Code == string(CodeRateLimited) {
+					t.Fatalf("first %s was rate limited", reqType)
+				}
+			}
+
+			tc.send(t, "2", Ping, nil)
+			res := tc.mustRecv(t)
+			if res.Id != "2" || res.Code != string(CodeRateLimited) {
+				t.Fatalf("got %+v, want ping 2 rate limited", res)
+			}
+			if res.Details["retry_after"] == "" {
+				t.Fatal("rate limited ping has no retry_after")
+			}
+		})
+	}
+}
+
+func TestRateLimitSharesIPBucketAcrossConnections(t *testing.T) {
+	cfg := testCfg()
+	cfg.RateLimit.PerIP = RateLimit{Rate: 0.01, Burst: 1}
+	s := newTestServer(cfg)
+
+	// both ends of a pipe have the same address
+	first, second := serveTestConn(t, s), serveTestConn(t, s)
+
+	first.send(t, "1", Ping, nil)
+	if res := first.mustRecv(t); res.Type != FramePong {
+		t.Fatalf("got %+v, want a pong", res)
+	}
+	second.send(t, "1", Ping, nil)
+	if res := second.mustRecv(t); res.Code != string(CodeRateLimited) {
+		t.Fatalf("got %+v, want the ping rate limited", res)
+	}
+}
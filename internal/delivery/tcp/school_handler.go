@@ -2,36 +2,135 @@ package tcp
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/mapper"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
 )
 
 func (s *server) CreateSchoolHandler(
 	ctx context.Context,
-	payload json.RawMessage,
+	payload Payload,
 ) (interface{}, error) {
 	var req dto.CreateSchoolReq
 
-	err := json.Unmarshal(payload, &req)
+	err := payload.Decode(&req)
 	if err != nil {
 		return nil, err
 	}
 
 	schoolUsecases := s.schoolUsecases
-	schoolId := schoolUsecases.CreateUseCase.Execute(req.Name)
+	schoolId, err := schoolUsecases.CreateUseCase.Execute(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
 
 	return schoolId, nil
 }
 
 func (s *server) ListSchoolsHandler(
 	ctx context.Context,
-	payload json.RawMessage,
+	payload Payload,
+) (interface{}, error) {
+	var req dto.ListReq
+
+	err := payload.decodeOptional(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := listOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	schoolUsecases := s.schoolUsecases
+	schools, next, err := schoolUsecases.ListUseCase.Execute(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if req.PageSize == 0 {
+		return mapper.SchoolsToDtos(schools), nil
+	}
+	return dto.SchoolPage{Items: mapper.SchoolsToDtos(schools), NextCursor: next}, nil
+}
+
+func (s *server) StreamSchoolsHandler(
+	ctx context.Context,
+	payload Payload,
+	send func(chunk interface{}) error,
+) error {
+	var req dto.StreamReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return err
+	}
+
+	opts := streamOptions(req)
+
+	schoolUsecases := s.schoolUsecases
+	return schoolUsecases.ListUseCase.ExecuteInBatches(ctx, opts, chunkSize(req), func(schools *[]entity.School) error {
+		return send(mapper.SchoolsToDtos(schools))
+	})
+}
+
+func (s *server) UpdateSchoolHandler(
+	ctx context.Context,
+	payload Payload,
+) (interface{}, error) {
+	var req dto.UpdateSchoolReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	schoolUsecases := s.schoolUsecases
+	school, err := schoolUsecases.UpdateUseCase.Execute(ctx, req.Id, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.SchoolToDto(school), nil
+}
+
+func (s *server) DeleteSchoolHandler(
+	ctx context.Context,
+	payload Payload,
+) (interface{}, error) {
+	var req dto.IdReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	schoolUsecases := s.schoolUsecases
+	err = schoolUsecases.DeleteUseCase.Execute(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return "school deleted successfully", nil
+}
+
+func (s *server) RestoreSchoolHandler(
+	ctx context.Context,
+	payload Payload,
 ) (interface{}, error) {
+	var req dto.IdReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
 	schoolUsecases := s.schoolUsecases
-	schools, err := schoolUsecases.ListUseCase.Execute()
+	err = schoolUsecases.RestoreUseCase.Execute(ctx, req.Id)
 	if err != nil {
 		return nil, err
 	}
-	return schools, nil
+
+	return "school restored successfully", nil
 }
@@ -0,0 +1,55 @@
+package tcp
+
+import (
+	"context"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/mapper"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchHandler answers with the schools, persons and classes matching
+// the query, best match first.
+func (s *server) SearchHandler(ctx context.Context, payload Payload) (interface{}, error) {
+	if s.searchUseCase == nil {
+		return nil, NewError(CodeUnimplemented, "search is not enabled")
+	}
+
+	var req dto.SearchReq
+
+	err := payload.Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := repository.SearchOptions{Limit: searchLimit(req)}
+	for _, kind := range req.Kinds {
+		opts.Kinds = append(opts.Kinds, entity.SearchKind(kind))
+	}
+
+	results, err := s.searchUseCase.Execute(ctx, req.Query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.SearchResultsToDtos(results), nil
+}
+
+// searchLimit returns the number of results asked for in req, within
+// limits.
+func searchLimit(req dto.SearchReq) int {
+	switch {
+	case req.Limit <= 0:
+		return defaultSearchLimit
+	case req.Limit > maxSearchLimit:
+		return maxSearchLimit
+	default:
+		return req.Limit
+	}
+}
@@ -3,81 +3,232 @@ package tcp
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"os"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/codec"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/class"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/person"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/purge"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/school"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/search"
 )
 
 type IServer interface {
 	Start(ctx context.Context) error
 	Shutdown() error
 	RegisterHandler(reqType RequestType, handler RequestHandler)
+	// RegisterStreamHandler registers a handler that replies with chunk
+	// frames followed by an end frame.
+	RegisterStreamHandler(reqType RequestType, handler StreamHandler)
+	// Use adds middlewares that run for every request type.
+	Use(mws ...Middleware)
+	// UseFor adds middlewares that only run for reqType, inside the
+	// global ones.
+	UseFor(reqType RequestType, mws ...Middleware)
+	// Panics returns how many handler panics have been recovered.
+	Panics() uint64
+	// Connections lists the open connections with their heartbeat stats.
+	Connections() []ConnectionInfo
 	ServerHandlers
 }
 
 type ServerHandlers interface {
-	CreateSchoolHandler(ctx context.Context, payload json.RawMessage) (interface{}, error)
-	ListSchoolsHandler(ctx context.Context, payload json.RawMessage) (interface{}, error)
-	CreatePersonHandler(ctx context.Context, payload json.RawMessage) (interface{}, error)
-	ListPersonsHandler(ctx context.Context, payload json.RawMessage) (interface{}, error)
-	CreateClassHandler(ctx context.Context, payload json.RawMessage) (interface{}, error)
-	ListClassesHandler(ctx context.Context, payload json.RawMessage) (interface{}, error)
-	AddStudentToClassHandler(ctx context.Context, payload json.RawMessage) (interface{}, error)
-	WhoAmIHandler(ctx context.Context, payload json.RawMessage) (interface{}, error)
+	CreateSchoolHandler(ctx context.Context, payload Payload) (interface{}, error)
+	ListSchoolsHandler(ctx context.Context, payload Payload) (interface{}, error)
+	CreatePersonHandler(ctx context.Context, payload Payload) (interface{}, error)
+	ListPersonsHandler(ctx context.Context, payload Payload) (interface{}, error)
+	CreateClassHandler(ctx context.Context, payload Payload) (interface{}, error)
+	ListClassesHandler(ctx context.Context, payload Payload) (interface{}, error)
+	AddStudentToClassHandler(ctx context.Context, payload Payload) (interface{}, error)
+	WhoAmIHandler(ctx context.Context, payload Payload) (interface{}, error)
+	LoginHandler(ctx context.Context, payload Payload) (interface{}, error)
+	LogoutHandler(ctx context.Context, payload Payload) (interface{}, error)
+	SetPasswordHandler(ctx context.Context, payload Payload) (interface{}, error)
+	ChangePasswordHandler(ctx context.Context, payload Payload) (interface{}, error)
+	ResetPasswordHandler(ctx context.Context, payload Payload) (interface{}, error)
+	UpdateSchoolHandler(ctx context.Context, payload Payload) (interface{}, error)
+	DeleteSchoolHandler(ctx context.Context, payload Payload) (interface{}, error)
+	UpdatePersonHandler(ctx context.Context, payload Payload) (interface{}, error)
+	DeletePersonHandler(ctx context.Context, payload Payload) (interface{}, error)
+	UpdateClassHandler(ctx context.Context, payload Payload) (interface{}, error)
+	DeleteClassHandler(ctx context.Context, payload Payload) (interface{}, error)
+	RestoreSchoolHandler(ctx context.Context, payload Payload) (interface{}, error)
+	RestorePersonHandler(ctx context.Context, payload Payload) (interface{}, error)
+	RestoreClassHandler(ctx context.Context, payload Payload) (interface{}, error)
+	PurgeHandler(ctx context.Context, payload Payload) (interface{}, error)
+	SearchHandler(ctx context.Context, payload Payload) (interface{}, error)
+	StreamSchoolsHandler(ctx context.Context, payload Payload, send func(chunk interface{}) error) error
+	StreamPersonsHandler(ctx context.Context, payload Payload, send func(chunk interface{}) error) error
+	StreamClassesHandler(ctx context.Context, payload Payload, send func(chunk interface{}) error) error
 }
 
 type SrvCfg struct {
 	Network         string
 	Address         string
+	Framing         Framing
 	MaxConnections  int
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	IdleTimeout     time.Duration
 	MaxMessageSize  int64
+	MaxInFlight     int
 	ShutdownTimeout time.Duration
+	// HeartbeatInterval enables pings to clients that said hello with
+	// version 2 or later, a client is evicted after HeartbeatMisses
+	// unanswered ones
+	HeartbeatInterval time.Duration
+	HeartbeatMisses   int
+	// RateLimit throttles requests with token buckets, zero rates
+	// disable it
+	RateLimit RateLimitCfg
+	Auth      AuthCfg
+	// TLS is optional, a nil config serves plain TCP
+	TLS *TLSConfig
+	// Unix only applies when Network is unix
+	Unix UnixSocketConfig
+	// Listeners replaces Network, Address, TLS and Unix when set
+	Listeners []ListenerCfg
 }
 
 type RequestType string
 
 const (
-	CreateSchool       RequestType = "creat_school"
-	ListSchools        RequestType = "list_schools"
-	CreatePerson       RequestType = "creat_person"
-	ListPersons        RequestType = "list_persons"
-	CreateClass        RequestType = "creat_class"
-	ListClasses        RequestType = "list_classes"
-	AddStudentToClass  RequestType = "add_student_to_class"
-	WhoAmI             RequestType = "who_am_i"
+	CreateSchool      RequestType = "create_school"
+	ListSchools       RequestType = "list_schools"
+	CreatePerson      RequestType = "create_person"
+	ListPersons       RequestType = "list_persons"
+	CreateClass       RequestType = "create_class"
+	ListClasses       RequestType = "list_classes"
+	AddStudentToClass RequestType = "add_student_to_class"
+	WhoAmI            RequestType = "who_am_i"
+	UpdateSchool      RequestType = "update_school"
+	DeleteSchool      RequestType = "delete_school"
+	UpdatePerson      RequestType = "update_person"
+	DeletePerson      RequestType = "delete_person"
+	UpdateClass       RequestType = "update_class"
+	DeleteClass       RequestType = "delete_class"
+	RestoreSchool     RequestType = "restore_school"
+	RestorePerson     RequestType = "restore_person"
+	RestoreClass      RequestType = "restore_class"
+	// Purge permanently removes records soft deleted longer ago than
+	// the server is configured to keep them.
+	Purge RequestType = "purge"
+	// Search finds schools, persons and classes by name, see SearchReq.
+	Search RequestType = "search"
+	// Login binds a person to the connection, see LoginReq, and Logout
+	// unbinds it.
+	Login  RequestType = "login"
+	Logout RequestType = "logout"
+	// SetPassword gives a person without a password one, ResetPassword
+	// replaces it and unlocks them, both for admins. ChangePassword is
+	// for the person logged in.
+	SetPassword    RequestType = "set_password"
+	ChangePassword RequestType = "change_password"
+	ResetPassword  RequestType = "reset_password"
+
+	// StreamSchools, StreamPersons and StreamClasses list like their list_*
+	// counterparts, but in chunk frames so the result can be bigger than
+	// MaxMessageSize, see StreamReq.
+	StreamSchools RequestType = "stream_schools"
+	StreamPersons RequestType = "stream_persons"
+	StreamClasses RequestType = "stream_classes"
+
+	// Hello negotiates the protocol version, codec and compression of the
+	// connection, see HelloReq. It is optional, handled by the server
+	// itself and must be sent before any other request.
+	Hello RequestType = "hello"
+	// SetCodec switches the codec of the connection. It is handled by the
+	// server itself and must be sent before any other request.
+	SetCodec RequestType = "set_codec"
+	// Subscribe and Unsubscribe start and stop event frames on the
+	// connection. They are handled by the server itself too.
+	Subscribe   RequestType = "subscribe"
+	Unsubscribe RequestType = "unsubscribe"
+	// Ping asks for a pong frame and Pong answers a ping frame, both are
+	// handled by the server itself and can be sent at any time.
+	Ping RequestType = "ping"
+	Pong RequestType = "pong"
+	// Batch runs a list of requests in one round trip, see BatchReq.
+	Batch RequestType = "batch"
 )
 
+// Types of frames the server sends without a matching request.
+const (
+	// FrameGoAway tells the client the server is shutting down and will
+	// close the connection, no more requests are read after it.
+	FrameGoAway = "goaway"
+	// FrameEvent carries a domain event the connection subscribed to.
+	FrameEvent = "event"
+	// FramePing must be answered with a Pong request echoing its id,
+	// FramePong answers a Ping request.
+	FramePing = "ping"
+	FramePong = "pong"
+	// FrameChunk carries part of the reply to a streaming request and
+	// FrameEnd finishes it, with the error if the stream failed. Both
+	// have the id of the request.
+	FrameChunk = "chunk"
+	FrameEnd   = "end"
+)
+
+// how long a request that isn't streamed may take
+const requestTimeout = 30 * time.Second
+
 type server struct {
 	// optional
 	cfg    *SrvCfg
 	logger *log.Logger
 
-	listener    net.Listener
+	listeners   []*listener
 	connections sync.Map
-	connCount   chan struct{}
-	handlers    map[RequestType]RequestHandler
-	wg          sync.WaitGroup
-	mu          sync.RWMutex
+	connIds     atomic.Uint64
+	closing     atomic.Bool
+	panics      atomic.Uint64
+	// cancels the context of in-flight requests once shutdown times out
+	cancel    context.CancelFunc
+	connCount chan struct{}
+	handlers  map[RequestType]RequestHandler
+	// the handlers wrapped in their middlewares, rebuilt when either
+	// changes so requests don't wrap them again
+	chained map[RequestType]RequestHandler
+	// request types registered with RegisterStreamHandler
+	streams map[RequestType]bool
+	// global middlewares and the ones registered per request type
+	middlewares     []Middleware
+	typeMiddlewares map[RequestType][]Middleware
+	limiter         *rateLimiter
+	policy          Policy
+	wg              sync.WaitGroup
+	mu              sync.RWMutex
 
 	schoolUsecases *school.SchoolUsecases
 	classUsecases  *class.ClassUsecases
 	personUsecases *person.PersonUsecases
+
+	// optional, subscribe requests fail without it
+	events      event.Subscriber
+	unsubscribe func()
+	// optional, atomic batches fail without it
+	transactor repository.Transactor
+	// optional, purge requests fail without it
+	purgeUseCase *purge.PurgeUseCase
+	// optional, search requests fail without it
+	searchUseCase *search.SearchUseCase
 }
 
-type RequestHandler func(ctx context.Context, payload json.RawMessage) (interface{}, error)
+type RequestHandler func(ctx context.Context, payload Payload) (interface{}, error)
 
 func NewServer(ops ...srvops) IServer {
 	s := &server{
@@ -85,25 +236,32 @@ func NewServer(ops ...srvops) IServer {
 		cfg: &SrvCfg{
 			Network:         "tcp",
 			Address:         ":8080",
+			Framing:         NewlineFraming,
 			MaxConnections:  1000,
 			ReadTimeout:     30 * time.Second,
 			WriteTimeout:    30 * time.Second,
 			IdleTimeout:     5 * time.Minute,
 			MaxMessageSize:  1024 * 1024, // 1MB
+			MaxInFlight:     64,
 			ShutdownTimeout: 30 * time.Second,
 		},
 		// default logger
-		logger:    log.New(os.Stdout, "[TCP Server]", log.LstdFlags),
-		connCount: make(chan struct{}, 1000),
-		handlers:  make(map[RequestType]RequestHandler),
-		wg:        sync.WaitGroup{},
-		mu:        sync.RWMutex{},
+		logger:          log.New(os.Stdout, "[TCP Server]", log.LstdFlags),
+		connCount:       make(chan struct{}, 1000),
+		handlers:        make(map[RequestType]RequestHandler),
+		chained:         make(map[RequestType]RequestHandler),
+		streams:         make(map[RequestType]bool),
+		typeMiddlewares: make(map[RequestType][]Middleware),
+		wg:              sync.WaitGroup{},
+		mu:              sync.RWMutex{},
 	}
 
 	// set options
 	for _, o := range ops {
 		o(s)
 	}
+	s.limiter = newRateLimiter(s.cfg.RateLimit)
+	s.policy = DefaultPolicy().With(s.cfg.Auth.Policy)
 	return s
 }
 
@@ -116,9 +274,11 @@ func WithCfg(cfg SrvCfg) srvops {
 	}
 }
 
-func WithLogger(l log.Logger) srvops {
+// WithLogger makes the server log to l. It takes a pointer because a
+// log.Logger holds a mutex and mustn't be copied.
+func WithLogger(l *log.Logger) srvops {
 	return func(s *server) {
-		s.logger = &l
+		s.logger = l
 	}
 }
 
@@ -140,15 +300,58 @@ func WithPersonUsecases(pu person.PersonUsecases) srvops {
 	}
 }
 
+func WithPurgeUseCase(pu *purge.PurgeUseCase) srvops {
+	return func(s *server) {
+		s.purgeUseCase = pu
+	}
+}
+
+func WithSearchUseCase(su *search.SearchUseCase) srvops {
+	return func(s *server) {
+		s.searchUseCase = su
+	}
+}
+
+func WithTransactor(t repository.Transactor) srvops {
+	return func(s *server) {
+		s.transactor = t
+	}
+}
+
+func WithEvents(sub event.Subscriber) srvops {
+	return func(s *server) {
+		s.events = sub
+	}
+}
+
 func (s *server) Start(ctx context.Context) error {
-	l, err := net.Listen(s.cfg.Network, s.cfg.Address)
-	if err != nil {
-		return fmt.Errorf("failed to start listener: %w", err)
+	if err := s.cfg.Framing.validate(); err != nil {
+		return err
+	}
+	if s.cfg.Framing == "" {
+		s.cfg.Framing = NewlineFraming
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	if s.events != nil {
+		s.unsubscribe = s.events.Subscribe(s.publish)
+	}
+
+	for _, lcfg := range s.cfg.listenerCfgs() {
+		l, err := listen(lcfg)
+		if err != nil {
+			s.closeListeners()
+			s.cancel()
+			return err
+		}
+		s.listeners = append(s.listeners, l)
+		s.logger.Printf("Server is listening on: %s %s\n", lcfg.Network, lcfg.Address)
 	}
-	s.listener = l
-	s.logger.Printf("Server is listening on: %s\n", s.cfg.Address)
 
-	go s.acceptConn(ctx)
+	for _, l := range s.listeners {
+		go s.acceptConn(ctx, l)
+	}
 
 	return nil
 }
@@ -157,15 +360,16 @@ func (s *server) RegisterHandler(reqType RequestType, handler RequestHandler) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.handlers[reqType] = handler
+	s.chained[reqType] = s.chain(reqType, handler)
 }
 
-func (s *server) acceptConn(ctx context.Context) {
+func (s *server) acceptConn(ctx context.Context, l *listener) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			conn, err := s.listener.Accept()
+			conn, err := l.Accept()
 			if err != nil {
 				return
 			}
@@ -173,7 +377,7 @@ func (s *server) acceptConn(ctx context.Context) {
 			select {
 			case s.connCount <- struct{}{}:
 				s.wg.Add(1)
-				go s.handleConn(ctx, conn)
+				go s.handleConn(ctx, conn, &l.cfg)
 			default:
 				s.logger.Println("Connection limit reached, rejecting connection")
 				conn.Close()
@@ -182,119 +386,375 @@ func (s *server) acceptConn(ctx context.Context) {
 	}
 }
 
-func (s *server) handleConn(ctx context.Context, conn net.Conn) {
+func (s *server) handleConn(ctx context.Context, conn net.Conn, lcfg *ListenerCfg) {
 	defer s.wg.Done()
 	defer conn.Close()
 	defer func() { <-s.connCount }()
 
-	clienAddr := conn.RemoteAddr().String()
-	s.connections.Store(clienAddr, conn)
-	defer s.connections.Delete(clienAddr)
+	c := newConnection(s.connIds.Add(1), conn, lcfg, s.cfg.MaxInFlight)
+	defer close(c.done)
+	// let in-flight requests finish writing before the conn is closed
+	defer c.inflight.Wait()
+
+	ctx = withSession(ctx, &c.session)
 
-	s.logger.Printf("New connection from %s\n", clienAddr)
-	defer s.logger.Printf("Connection closed: %s\n", clienAddr)
+	s.connections.Store(c.id, c)
+	defer s.connections.Delete(c.id)
+
+	// accepted while Shutdown was already draining the others
+	if s.closing.Load() {
+		c.drain()
+	}
 
-	reader := bufio.NewReader(conn)
-	conn.SetDeadline(time.Now().Add(s.cfg.IdleTimeout))
+	s.logger.Printf("New connection from %s\n", c.addr)
+	defer s.logger.Printf("Connection closed: %s\n", c.addr)
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn.SetDeadline(time.Now().Add(lcfg.ReadTimeout))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			s.logger.Printf("TLS handshake failed for %s: %v\n", c.addr, err)
+			return
+		}
+
+		if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+			ctx = withClientCertSubject(ctx, certs[0].Subject)
+		}
+	}
+
+	if unixConn, ok := conn.(*net.UnixConn); ok {
+		cred, err := peerCredentials(unixConn)
+		if err != nil {
+			s.logger.Printf("Failed to read peer credentials for %s: %v\n", c.addr, err)
+		} else {
+			ctx = withPeerCredentials(ctx, cred)
+		}
+	}
+
+	reader := newFrameReader(s.cfg.Framing, bufio.NewReader(conn), s.cfg.MaxMessageSize)
+	conn.SetDeadline(time.Now().Add(lcfg.IdleTimeout))
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			conn.SetReadDeadline(time.Now().Add(s.cfg.ReadTimeout))
-			line, err := reader.ReadBytes('\n')
+			c.armReadDeadline()
+			if c.draining.Load() {
+				s.goAway(c)
+				return
+			}
+
+			frame, err := reader.ReadFrame()
 			if err != nil {
+				if c.draining.Load() {
+					s.goAway(c)
+					return
+				}
+				if err == ErrFrameTooLarge {
+					// the rest of the frame is left unread, so the
+					// connection can't go on after it
+					s.write(c, errorResponse("", NewError(CodeInvalidArgument, "message too large")))
+					s.logger.Printf("Frame too large from %s\n", c.addr)
+					return
+				}
 				if err == io.EOF {
 					return
 				}
 				if ne, ok := err.(net.Error); ok && ne.Timeout() {
-					s.logger.Printf("Read timeout for %s\n", clienAddr)
+					s.logger.Printf("Read timeout for %s\n", c.addr)
 					return
 				}
-				s.logger.Printf("Read error for %s: %v\n", clienAddr, err)
+				s.logger.Printf("Read error for %s: %v\n", c.addr, err)
 				return
 			}
 
-			if int64(len(line)) > s.cfg.MaxMessageSize {
-				s.json(conn, dto.Response{
-					Status:  false,
-					Message: "message too large",
-				})
+			conn.SetDeadline(time.Now().Add(lcfg.IdleTimeout))
+
+			frame, err = c.compression.decompress(frame, s.cfg.MaxMessageSize)
+			if err != nil {
+				s.write(c, errorResponse("", NewError(CodeInvalidArgument, err.Error())))
+				continue
+			}
+
+			var req dto.Request
+			if err := c.codec.Unmarshal(frame, &req); err != nil {
+				s.write(c, errorResponse("", NewError(CodeInvalidArgument, "invalid message format")))
 				continue
 			}
 
-			conn.SetDeadline(time.Now().Add(s.cfg.IdleTimeout))
-			s.processRequest(ctx, conn, line)
+			switch RequestType(req.Type) {
+			case Ping, Pong, Hello, SetCodec, Subscribe, Unsubscribe:
+				if s.throttle(ctx, c, req) {
+					continue
+				}
+			}
+
+			switch RequestType(req.Type) {
+			case Ping:
+				s.handlePing(c, req)
+				continue
+			case Pong:
+				s.handlePong(c, req)
+				continue
+			}
+
+			if RequestType(req.Type) == Hello {
+				s.hello(c, req)
+				continue
+			}
+
+			if RequestType(req.Type) == SetCodec {
+				s.setCodec(c, req)
+				continue
+			}
+			c.dispatched++
+
+			if t := RequestType(req.Type); t == Subscribe || t == Unsubscribe {
+				s.subscribe(c, req)
+				continue
+			}
+
+			// stop reading while the connection is at its in-flight limit
+			select {
+			case c.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			c.inflight.Add(1)
+			c.active.Add(1)
+			go func() {
+				defer c.inflight.Done()
+				defer c.finish()
+				defer func() { <-c.slots }()
+				s.processRequest(ctx, c, req)
+			}()
 		}
 	}
 }
 
-func (s *server) processRequest(ctx context.Context, conn net.Conn, data []byte) {
-	var req dto.Request
+// throttle charges a request the read loop answers itself against the
+// rate limits and reports whether it was refused. Pongs answer the server,
+// a refused one is dropped without a reply.
+func (s *server) throttle(ctx context.Context, c *connection, req dto.Request) bool {
+	wait, ok := s.limiter.allow(c, identity(ctx), RequestType(req.Type))
+	if ok {
+		return false
+	}
+	if RequestType(req.Type) != Pong {
+		s.write(c, errorResponse(req.Id, rateLimited(wait)))
+	}
+	return true
+}
+
+func (s *server) setCodec(c *connection, req dto.Request) {
+	var body dto.SetCodecReq
+	if err := c.codec.Unmarshal(req.Payload, &body); err != nil {
+		s.write(c, errorResponse(req.Id, NewError(CodeInvalidArgument, "invalid message format")))
+		return
+	}
+
+	if c.dispatched > 0 {
+		s.write(c, errorResponse(req.Id, NewError(CodeFailedPrecondition,
+			"codec must be negotiated before any other request")))
+		return
+	}
+
+	cd, err := codec.Get(body.Name)
+	if err != nil {
+		s.write(c, errorResponse(req.Id, NewError(CodeInvalidArgument, err.Error())))
+		return
+	}
+
+	if cd.Binary() && s.cfg.Framing != LengthPrefixedFraming {
+		s.write(c, errorResponse(req.Id, NewError(CodeFailedPrecondition,
+			fmt.Sprintf("codec %s requires length-prefixed framing", cd.Name()))))
+		return
+	}
+
+	// acknowledge with the old codec, everything after uses the new one
+	s.write(c, dto.Response{
+		Id:     req.Id,
+		Status: true,
+	})
+	c.switchTo(cd, c.compression)
+}
+
+func (s *server) processRequest(ctx context.Context, c *connection, req dto.Request) {
+	// streams run for as long as they keep sending, every chunk has the
+	// write timeout to go out
+	if s.isStream(RequestType(req.Type), c.version) {
+		s.write(c, s.stream(ctx, c, req))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
 
-	if err := json.Unmarshal(data, &req); err != nil {
-		s.json(conn, dto.Response{
-			Status:  false,
-			Message: "invalid JSON format",
-		})
+	if RequestType(req.Type) == Batch {
+		s.write(c, s.batch(ctx, c, req))
 		return
 	}
+	s.write(c, s.execute(ctx, c, req))
+}
+
+// execute runs req on its handler and returns the response to send.
+func (s *server) execute(ctx context.Context, c *connection, req dto.Request) dto.Response {
+	reqType, err := resolveRequestType(RequestType(req.Type), c.version)
+	if err != nil {
+		return errorResponse(req.Id, toError(err))
+	}
+
+	if wait, ok := s.limiter.allow(c, identity(ctx), reqType); !ok {
+		return errorResponse(req.Id, rateLimited(wait))
+	}
+	if err := s.authorize(c, reqType); err != nil {
+		return errorResponse(req.Id, err)
+	}
+	ctx = entity.WithActor(ctx, actor(c.session.get()))
 
 	s.mu.RLock()
-	handler, ok := s.handlers[RequestType(req.Type)]
+	handler, ok := s.chained[reqType]
 	s.mu.RUnlock()
 
 	if !ok {
-		s.json(conn, dto.Response{
-			Status:  false,
-			Message: fmt.Sprintf("unknown request type: %s", req.Type),
-		})
-		return
+		return errorResponse(req.Id, NewError(CodeUnimplemented,
+			fmt.Sprintf("unknown request type: %s", req.Type)))
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+	ctx = withRequestInfo(ctx, RequestInfo{
+		Id:         req.Id,
+		Type:       reqType,
+		RemoteAddr: c.addr,
+	})
 
-	result, err := handler(ctx, req.Payload)
+	result, err := s.invoke(ctx, handler, Payload{data: req.Payload, codec: c.codec})
 	if err != nil {
-		s.json(conn, dto.Response{
-			Status:  false,
-			Message: err.Error(),
-		})
-		return
+		e := toError(err)
+		if e.Code == CodeInternal {
+			s.logger.Printf("Request %s (%s) failed: %v\n", req.Id, req.Type, err)
+		}
+		return errorResponse(req.Id, e)
 	}
 
-	s.json(conn, dto.Response{
+	data, err := c.codec.Marshal(result)
+	if err != nil {
+		s.logger.Printf("Failed to encode result of %s: %v\n", req.Type, err)
+		return errorResponse(req.Id, NewError(CodeInternal, "failed to encode response"))
+	}
+
+	return dto.Response{
+		Id:     req.Id,
 		Status: true,
-		Data:   result,
-	})
+		Data:   data,
+	}
+}
+
+// invoke calls handler, turning a panic into an internal error so only
+// the request that caused it fails.
+func (s *server) invoke(ctx context.Context, handler RequestHandler, payload Payload) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.panics.Add(1)
+			info, _ := RequestInfoFromContext(ctx)
+			s.logger.Printf("Panic in %s handler, request %s from %s: %v\n%s",
+				info.Type, info.Id, info.RemoteAddr, r, debug.Stack())
+			result, err = nil, NewError(CodeInternal, "internal error")
+		}
+	}()
+
+	return handler(ctx, payload)
+}
+
+func (s *server) Panics() uint64 {
+	return s.panics.Load()
 }
 
-func (s *server) json(conn net.Conn, res dto.Response) {
-	conn.SetWriteDeadline(time.Now().Add(s.cfg.WriteTimeout))
+// write sends res on c. Failures are logged, the error is only returned
+// for callers that stop sending after it.
+func (s *server) write(c *connection, res dto.Response) error {
+	// the codec and compression only change under wmu
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
 
-	data, err := json.Marshal(res)
+	data, err := c.codec.Marshal(res)
 	if err != nil {
 		s.logger.Printf("Failed to marshal response: %v\n", err)
-		return
+		return err
+	}
+
+	data, err = c.compression.compress(data)
+	if err != nil {
+		s.logger.Printf("Failed to compress response: %v\n", err)
+		return err
 	}
 
-	data = append(data, '\n')
+	data = encodeFrame(s.cfg.Framing, data)
 
-	if _, err = conn.Write(data); err != nil {
+	c.SetWriteDeadline(time.Now().Add(c.cfg.WriteTimeout))
+	if _, err = c.Write(data); err != nil {
 		if ne, ok := err.(net.Error); ok && ne.Timeout() {
 			s.logger.Printf("Wrire timeout")
-			return
+			return err
 		}
 		s.logger.Printf("Failed to write response: %v\n", err)
+		return err
 	}
+	return nil
+}
+
+// goAway waits for the requests in flight on c and then tells the client
+// the connection is about to close.
+func (s *server) goAway(c *connection) {
+	c.inflight.Wait()
+	s.write(c, dto.Response{
+		Type:    FrameGoAway,
+		Message: "server going away",
+	})
 }
 
 func (s *server) Shutdown() error {
 	s.logger.Println("Starting graceful shutdown...")
-	if err := s.listener.Close(); err != nil {
-		s.logger.Printf("Error closing listener: %v\n", err)
+	s.closing.Store(true)
+	s.closeListeners()
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+	}
+
+	s.connections.Range(func(_, v any) bool {
+		v.(*connection).drain()
+		return true
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Println("All connections drained")
+		return nil
+	case <-time.After(s.cfg.ShutdownTimeout):
+	}
+
+	s.logger.Println("Shutdown timeout reached, closing remaining connections")
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.connections.Range(func(_, v any) bool {
+		v.(*connection).Close()
+		return true
+	})
+
+	return fmt.Errorf("shutdown timed out after %s", s.cfg.ShutdownTimeout)
+}
+
+func (s *server) closeListeners() {
+	for _, l := range s.listeners {
+		if err := l.Close(); err != nil {
+			s.logger.Printf("Error closing listener %s: %v\n", l.cfg.Address, err)
+		}
 	}
-	return nil
 }
@@ -0,0 +1,258 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	store "github.com/arashalaei/go-clean-socket-architecture/internal/repository/sqlite"
+)
+
+// testConn is the client end of a connection served by a server under
+// test, speaking json over newline framing.
+type testConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func newTestServer(cfg SrvCfg) *server {
+	return NewServer(WithCfg(cfg), WithLogger(log.New(io.Discard, "", 0))).(*server)
+}
+
+// newTestDB returns a fresh store in a temporary directory.
+func newTestDB(t *testing.T) store.IStore {
+	t.Helper()
+
+	db, err := store.NewSqlite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func testCfg() SrvCfg {
+	return SrvCfg{
+		Framing:        NewlineFraming,
+		MaxConnections: 10,
+		ReadTimeout:    time.Second,
+		WriteTimeout:   time.Second,
+		IdleTimeout:    time.Minute,
+		MaxMessageSize: 1024 * 1024,
+		MaxInFlight:    64,
+	}
+}
+
+// serveTestConn hands s one end of a pipe and returns the other.
+func serveTestConn(t *testing.T, s *server) *testConn {
+	t.Helper()
+
+	client, conn := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	lcfg := &ListenerCfg{
+		ReadTimeout:  s.cfg.ReadTimeout,
+		WriteTimeout: s.cfg.WriteTimeout,
+		IdleTimeout:  s.cfg.IdleTimeout,
+	}
+
+	s.wg.Add(1)
+	s.connCount <- struct{}{}
+	go s.handleConn(ctx, conn, lcfg)
+	t.Cleanup(func() {
+		cancel()
+		client.Close()
+		s.wg.Wait()
+	})
+	return &testConn{Conn: client, r: bufio.NewReader(client)}
+}
+
+func (tc *testConn) send(t *testing.T, id string, reqType RequestType, payload any) {
+	t.Helper()
+
+	req := dto.Request{Id: id, Type: string(reqType)}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Payload = data
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	if _, err := tc.Write(append(data, '\n')); err != nil {
+		t.Fatalf("send %s: %v", reqType, err)
+	}
+}
+
+// recv returns the next frame of the server, or an error once the server
+// closed the connection.
+func (tc *testConn) recv() (dto.Response, error) {
+	var res dto.Response
+	tc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := tc.r.ReadBytes('\n')
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(line, &res)
+	return res, err
+}
+
+func (tc *testConn) mustRecv(t *testing.T) dto.Response {
+	t.Helper()
+
+	res, err := tc.recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	return res
+}
+
+func TestConcurrentDispatch(t *testing.T) {
+	cfg := testCfg()
+	cfg.MaxInFlight = 3
+	s := newTestServer(cfg)
+
+	started := make(chan string, 10)
+	release := make(map[string]chan struct{})
+	for _, id := range []string{"1", "2", "3", "4"} {
+		release[id] = make(chan struct{})
+	}
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		info, _ := RequestInfoFromContext(ctx)
+		started <- info.Id
+		<-release[info.Id]
+		return info.Id, nil
+	})
+
+	tc := serveTestConn(t, s)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		tc.send(t, id, ListSchools, nil)
+	}
+
+	waitStarted := func() string {
+		t.Helper()
+		select {
+		case id := <-started:
+			return id
+		case <-time.After(2 * time.Second):
+			t.Fatal("request didn't start")
+			return ""
+		}
+	}
+	for i := 0; i < cfg.MaxInFlight; i++ {
+		waitStarted()
+	}
+	select {
+	case id := <-started:
+		t.Fatalf("request %s started beyond the in-flight limit", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// replies go out as requests finish, not in the order they came in
+	for _, id := range []string{"3", "2"} {
+		close(release[id])
+		if res := tc.mustRecv(t); res.Id != id || !res.Status {
+			t.Fatalf("got %+v, want the reply to %s", res, id)
+		}
+	}
+	if id := waitStarted(); id != "4" {
+		t.Fatalf("request %s started, want 4", id)
+	}
+	for _, id := range []string{"4", "1"} {
+		close(release[id])
+		if res := tc.mustRecv(t); res.Id != id || !res.Status {
+			t.Fatalf("got %+v, want the reply to %s", res, id)
+		}
+	}
+}
+
+func TestConcurrentRepliesStayWhole(t *testing.T) {
+	cfg := testCfg()
+	cfg.MaxInFlight = 16
+	s := newTestServer(cfg)
+
+	big := strings.Repeat("x", 64*1024)
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		return big, nil
+	})
+
+	tc := serveTestConn(t, s)
+	const n = 50
+	// send while reading, the replies would block the requests otherwise
+	go func() {
+		for i := 0; i < n; i++ {
+			tc.Write([]byte(`{"id":"` + strconv.Itoa(i) + `","type":"list_schools"}` + "\n"))
+		}
+	}()
+
+	seen := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		res := tc.mustRecv(t)
+		var data string
+		if err := json.Unmarshal(res.Data, &data); err != nil || data != big {
+			t.Fatalf("reply %s is garbled: %v", res.Id, err)
+		}
+		seen[res.Id] = true
+	}
+	if len(seen) != n {
+		t.Fatalf("got %d distinct replies, want %d", len(seen), n)
+	}
+}
+
+// A frame that arrives in parts while a request is in flight must not be
+// cut in two by the read timeout.
+func TestReadTimeoutKeepsPartialFrames(t *testing.T) {
+	cfg := testCfg()
+	cfg.ReadTimeout = 50 * time.Millisecond
+	s := newTestServer(cfg)
+
+	release := make(chan struct{})
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		if info, _ := RequestInfoFromContext(ctx); info.Id == "slow" {
+			<-release
+		}
+		return "ok", nil
+	})
+
+	tc := serveTestConn(t, s)
+	var once sync.Once
+	// runs before the cleanup of serveTestConn waits for the handler
+	t.Cleanup(func() { once.Do(func() { close(release) }) })
+	tc.send(t, "slow", ListSchools, nil)
+
+	frame := []byte(`{"id":"fast","type":"list_schools"}` + "\n")
+	half := len(frame) / 2
+	if _, err := tc.Write(frame[:half]); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * cfg.ReadTimeout)
+	if _, err := tc.Write(frame[half:]); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := tc.mustRecv(t); res.Id != "fast" || !res.Status {
+		t.Fatalf("got %+v, want the reply to fast", res)
+	}
+	once.Do(func() { close(release) })
+	if res := tc.mustRecv(t); res.Id != "slow" || !res.Status {
+		t.Fatalf("got %+v, want the reply to slow", res)
+	}
+
+	// idle again, the read timeout closes the connection
+	if res, err := tc.recv(); err == nil {
+		t.Fatalf("got %+v, want the connection closed", res)
+	}
+}
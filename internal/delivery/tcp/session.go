@@ -0,0 +1,97 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+// Identity is who a connection logged in as.
+type Identity struct {
+	PersonId uint
+	Name     string
+	Role     entity.Role
+}
+
+// AuthCfg decides what connections may do before they log in.
+type AuthCfg struct {
+	// Required limits connections that haven't logged in to the Allow
+	// request types and login. Otherwise they may also send what the
+	// policy allows anonymousRole.
+	Required bool
+	Allow    []RequestType
+	// Policy overrides DefaultPolicy for the request types it lists
+	Policy Policy
+}
+
+// session holds the identity of a connection, it lives as long as the
+// connection and is shared by all its requests.
+type session struct {
+	mu       sync.RWMutex
+	identity *Identity
+}
+
+func (s *session) get() (Identity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return Identity{}, false
+	}
+	return *s.identity, true
+}
+
+func (s *session) set(identity *Identity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identity = identity
+}
+
+type sessionKey struct{}
+
+func withSession(ctx context.Context, s *session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+func sessionFromContext(ctx context.Context) *session {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	if !ok {
+		return &session{}
+	}
+	return s
+}
+
+// SessionIdentity returns who the connection a request arrived on is
+// logged in as.
+func SessionIdentity(ctx context.Context) (Identity, bool) {
+	return sessionFromContext(ctx).get()
+}
+
+// anonymousRole is the role connections that didn't log in act as, the
+// least privileged one.
+const anonymousRole = entity.StudentRole
+
+// actor returns who the use cases run on behalf of for a connection with
+// the given session.
+func actor(identity Identity, loggedIn bool) entity.Actor {
+	if !loggedIn {
+		return entity.Actor{Role: anonymousRole}
+	}
+	return entity.Actor{PersonId: identity.PersonId, Role: identity.Role}
+}
+
+// authenticate rejects reqType for connections that didn't log in unless
+// it is allowed to them, either explicitly or, when logins aren't
+// required, by the policy of anonymousRole.
+func (s *server) authenticate(reqType RequestType) *Error {
+	switch {
+	case reqType == Login, slices.Contains(s.cfg.Auth.Allow, reqType):
+		return nil
+	case !s.cfg.Auth.Required && s.policy.allows(anonymousRole, reqType):
+		return nil
+	}
+	return NewError(CodeUnauthenticated, fmt.Sprintf("login required for %s", reqType))
+}
@@ -0,0 +1,120 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/usecase/person"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSessions(t *testing.T) {
+	db := newTestDB(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("password1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[entity.Role]uint)
+	for _, role := range []entity.Role{entity.StudentRole, entity.TeacherRole, entity.AdminRole} {
+		id, err := db.CreatePerson(context.Background(), &entity.Person{
+			Name:         string(role),
+			Role:         role,
+			PasswordHash: string(hash),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[role] = id
+	}
+
+	cfg := testCfg()
+	cfg.Auth = AuthCfg{Required: true, Allow: []RequestType{ListSchools}}
+	personUsecases := person.NewPersonUseCases(
+		nil, nil,
+		person.NewWhoAmIUseCase(db),
+		nil,
+		person.NewLoginUseCase(db, person.Lockout{}),
+		nil, nil, nil, nil, nil, nil,
+	)
+	s := NewServer(
+		WithCfg(cfg),
+		WithLogger(log.New(io.Discard, "", 0)),
+		WithPersonUsecases(*personUsecases),
+	).(*server)
+	s.RegisterHandler(Login, s.LoginHandler)
+	s.RegisterHandler(Logout, s.LogoutHandler)
+	s.RegisterHandler(WhoAmI, s.WhoAmIHandler)
+	for _, reqType := range []RequestType{ListSchools, ListPersons, Purge} {
+		s.RegisterHandler(reqType, func(ctx context.Context, payload Payload) (interface{}, error) {
+			return "ok", nil
+		})
+	}
+	tc := serveTestConn(t, s)
+
+	// expect sends reqType and checks the code of the reply, empty for
+	// success
+	expect := func(reqType RequestType, payload any, want ErrorCode) dto.Response {
+		t.Helper()
+
+		tc.send(t, string(reqType), reqType, payload)
+		res := tc.mustRecv(t)
+		if res.Code != string(want) || res.Status != (want == "") {
+			t.Fatalf("%s: got %+v, want code %q", reqType, res, want)
+		}
+		return res
+	}
+	login := func(role entity.Role) {
+		t.Helper()
+		expect(Login, dto.LoginReq{PersonId: ids[role], Password: "password1"}, "")
+	}
+
+	// only the allowlist before logging in
+	expect(ListSchools, nil, "")
+	expect(ListPersons, nil, CodeUnauthenticated)
+	expect(WhoAmI, nil, CodeUnauthenticated)
+	expect(Login, dto.LoginReq{PersonId: ids[entity.AdminRole], Password: "wrong"}, CodeUnauthenticated)
+	expect(Purge, nil, CodeUnauthenticated)
+
+	login(entity.StudentRole)
+	res := expect(WhoAmI, nil, "")
+	var me dto.Person
+	if err := json.Unmarshal(res.Data, &me); err != nil {
+		t.Fatal(err)
+	}
+	if me.Id != ids[entity.StudentRole] {
+		t.Fatalf("who_am_i answered %+v, want the student", me)
+	}
+	expect(ListSchools, nil, "")
+	expect(ListPersons, nil, CodePermissionDenied)
+	expect(Purge, nil, CodePermissionDenied)
+
+	// a new login replaces the identity of the connection
+	login(entity.TeacherRole)
+	expect(ListPersons, nil, "")
+	expect(Purge, nil, CodePermissionDenied)
+
+	// a failed one keeps it
+	expect(Login, dto.LoginReq{PersonId: ids[entity.AdminRole], Password: "wrong"}, CodeUnauthenticated)
+	expect(ListPersons, nil, "")
+	expect(Purge, nil, CodePermissionDenied)
+
+	login(entity.AdminRole)
+	expect(Purge, nil, "")
+
+	// sessions belong to their connection
+	other := serveTestConn(t, s)
+	other.send(t, "1", WhoAmI, nil)
+	if res := other.mustRecv(t); res.Code != string(CodeUnauthenticated) {
+		t.Fatalf("other connection got %+v, want it logged out", res)
+	}
+
+	expect(Logout, nil, "")
+	expect(WhoAmI, nil, CodeUnauthenticated)
+	expect(Purge, nil, CodeUnauthenticated)
+	expect(ListSchools, nil, "")
+}
@@ -0,0 +1,119 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// dialTestConn connects to a server started with startTestServer.
+func dialTestConn(t *testing.T, addr string) *testConn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testConn{Conn: conn, r: bufio.NewReader(conn)}
+}
+
+func TestShutdownDrainsConnections(t *testing.T) {
+	cfg := testCfg()
+	cfg.ShutdownTimeout = 2 * time.Second
+	s := newTestServer(cfg)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		close(started)
+		<-release
+		return "done", nil
+	})
+	addr := startTestServer(t, s)
+
+	busy, idle := dialTestConn(t, addr), dialTestConn(t, addr)
+	waitFor(t, "both connections", func() bool {
+		n := 0
+		s.connections.Range(func(_, _ any) bool { n++; return true })
+		return n == 2
+	})
+	busy.send(t, "1", ListSchools, nil)
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown() }()
+
+	// the idle client is told right away
+	if res := idle.mustRecv(t); res.Type != FrameGoAway {
+		t.Fatalf("idle client got %+v, want goaway", res)
+	}
+	if _, err := idle.recv(); err != io.EOF {
+		t.Fatalf("idle connection wasn't closed after goaway: %v", err)
+	}
+
+	// new connections are refused while draining
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		t.Fatal("listener still accepts connections")
+	}
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v before the request finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	// the request in flight completes before the goaway
+	if res := busy.mustRecv(t); res.Id != "1" || !res.Status {
+		t.Fatalf("got %+v, want the reply to the request in flight", res)
+	}
+	if res := busy.mustRecv(t); res.Type != FrameGoAway {
+		t.Fatalf("got %+v, want goaway", res)
+	}
+	if _, err := busy.recv(); err != io.EOF {
+		t.Fatalf("connection wasn't closed after goaway: %v", err)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	cfg := testCfg()
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+	s := newTestServer(cfg)
+
+	started, canceled := make(chan struct{}), make(chan struct{})
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+	tc := dialTestConn(t, startTestServer(t, s))
+
+	tc.send(t, "1", ListSchools, nil)
+	<-started
+
+	if err := s.Shutdown(); err == nil {
+		t.Fatal("Shutdown didn't report the connection it had to cut")
+	}
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler context wasn't canceled")
+	}
+	for {
+		if _, err := tc.recv(); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("connection wasn't closed")
+			}
+			break
+		}
+	}
+}
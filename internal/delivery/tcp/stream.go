@@ -0,0 +1,213 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+const (
+	defaultChunkSize = 100
+	maxChunkSize     = 1000
+	// chunk frames the client read loop queues before waiting for the
+	// reader of the stream
+	chunkBuffer = 4
+)
+
+// StreamHandler replies to a request with a chunk frame for every call to
+// send. The end frame is sent once it returns. Streams have no deadline,
+// send fails once a chunk can't be written within the write timeout.
+type StreamHandler func(ctx context.Context, payload Payload, send func(chunk interface{}) error) error
+
+type streamKey struct{}
+
+// RegisterStreamHandler registers handler like RegisterHandler does, so
+// middlewares run for it the same way. Streaming requests can't be
+// batched.
+func (s *server) RegisterStreamHandler(reqType RequestType, handler StreamHandler) {
+	s.RegisterHandler(reqType, func(ctx context.Context, payload Payload) (interface{}, error) {
+		send, ok := ctx.Value(streamKey{}).(func(chunk interface{}) error)
+		if !ok {
+			return nil, NewError(CodeInvalidArgument,
+				fmt.Sprintf("%s can only be streamed", reqType))
+		}
+		return nil, handler(ctx, payload, send)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[reqType] = true
+}
+
+func (s *server) isStream(reqType RequestType, version int) bool {
+	reqType, err := resolveRequestType(reqType, version)
+	if err != nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.streams[reqType]
+}
+
+// stream runs a streaming request, writing its chunk frames as the handler
+// produces them, and returns the end frame.
+func (s *server) stream(ctx context.Context, c *connection, req dto.Request) dto.Response {
+	send := func(chunk interface{}) error {
+		data, err := c.codec.Marshal(chunk)
+		if err != nil {
+			return fmt.Errorf("failed to encode chunk: %w", err)
+		}
+		if err := s.write(c, dto.Response{
+			Id:     req.Id,
+			Status: true,
+			Data:   data,
+			Type:   FrameChunk,
+		}); err != nil {
+			return fmt.Errorf("failed to send chunk: %w", err)
+		}
+		return ctx.Err()
+	}
+
+	res := s.execute(context.WithValue(ctx, streamKey{}, send), c, req)
+	res.Data = nil
+	res.Type = FrameEnd
+	return res
+}
+
+// chunkSize returns the chunk size asked for in req, within limits.
+func chunkSize(req dto.StreamReq) int {
+	switch {
+	case req.ChunkSize <= 0:
+		return defaultChunkSize
+	case req.ChunkSize > maxChunkSize:
+		return maxChunkSize
+	default:
+		return req.ChunkSize
+	}
+}
+
+// Stream sends a streaming request and yields its chunk frames as they
+// arrive. It stops after the end frame, yielding its error if the stream
+// failed. Breaking out of the loop early drops the remaining chunks.
+func (c *Client) Stream(
+	ctx context.Context,
+	requestType RequestType,
+	payload interface{},
+) iter.Seq2[*dto.Response, error] {
+	return func(yield func(*dto.Response, error) bool) {
+		c.mu.RLock()
+		if c.conn == nil || c.closed {
+			c.mu.RUnlock()
+			yield(nil, fmt.Errorf("not connected"))
+			return
+		}
+		version := c.version
+		c.mu.RUnlock()
+
+		cd := c.currentCodec()
+
+		payloadBytes, err := cd.Marshal(payload)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to marshal payload: %w", err))
+			return
+		}
+
+		req := dto.Request{
+			Id:      strconv.FormatUint(c.nextId.Add(1), 10),
+			Type:    string(legacyRequestType(requestType, version)),
+			Payload: payloadBytes,
+		}
+
+		reqBytes, err := cd.Marshal(req)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to marshal request: %w", err))
+			return
+		}
+
+		call := &pendingCall{
+			ch:     make(chan *dto.Response, chunkBuffer),
+			stream: true,
+			done:   make(chan struct{}),
+		}
+		c.pendingMu.Lock()
+		c.pending[req.Id] = call
+		c.pendingMu.Unlock()
+
+		defer func() {
+			c.pendingMu.Lock()
+			delete(c.pending, req.Id)
+			c.pendingMu.Unlock()
+			close(call.done)
+		}()
+
+		if err := c.write(ctx, reqBytes); err != nil {
+			yield(nil, err)
+			return
+		}
+
+		// the read timeout applies to every frame, not the whole stream
+		timer := time.NewTimer(c.config.ReadTimeout)
+		defer timer.Stop()
+
+		for {
+			select {
+			case resp, ok := <-call.ch:
+				if !ok {
+					yield(nil, fmt.Errorf("connection closed"))
+					return
+				}
+				if resp.Type != FrameChunk {
+					if err := ResponseError(resp); err != nil {
+						yield(nil, err)
+					}
+					return
+				}
+				if !yield(resp, nil) {
+					return
+				}
+				timer.Reset(c.config.ReadTimeout)
+			case <-timer.C:
+				yield(nil, fmt.Errorf("timeout for read"))
+				return
+			case <-ctx.Done():
+				yield(nil, ctx.Err())
+				return
+			}
+		}
+	}
+}
+
+// StreamItems streams a request whose chunks are lists of T, like the
+// stream_* requests, and yields the items one at a time.
+func StreamItems[T any](
+	ctx context.Context,
+	c *Client,
+	requestType RequestType,
+	payload interface{},
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		for chunk, err := range c.Stream(ctx, requestType, payload) {
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var items []T
+			if err := c.Decode(chunk, &items); err != nil {
+				yield(zero, fmt.Errorf("failed to decode chunk: %w", err))
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
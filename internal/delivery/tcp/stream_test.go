@@ -0,0 +1,155 @@
+package tcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/delivery/tcp/dto"
+)
+
+// startTestServer starts s, on a loopback port unless its config has an
+// address, and returns the address it listens on.
+func startTestServer(t *testing.T, s *server) string {
+	t.Helper()
+
+	if s.cfg.Network == "" {
+		s.cfg.Network, s.cfg.Address = "tcp", "127.0.0.1:0"
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Shutdown() })
+	return s.listeners[0].Addr().String()
+}
+
+func TestStreamFrames(t *testing.T) {
+	s := newTestServer(testCfg())
+	s.RegisterStreamHandler(StreamSchools, func(ctx context.Context, payload Payload, send func(chunk interface{}) error) error {
+		if _, ok := ctx.Deadline(); ok {
+			return errors.New("stream has a deadline")
+		}
+		for i := 1; i <= 3; i++ {
+			if err := send([]int{i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	s.RegisterStreamHandler(StreamClasses, func(ctx context.Context, payload Payload, send func(chunk interface{}) error) error {
+		if err := send([]int{1}); err != nil {
+			return err
+		}
+		return NewError(CodeNotFound, "gone")
+	})
+	tc := serveTestConn(t, s)
+
+	tc.send(t, "s", StreamSchools, nil)
+	for i := 1; i <= 3; i++ {
+		res := tc.mustRecv(t)
+		var chunk []int
+		if err := json.Unmarshal(res.Data, &chunk); err != nil {
+			t.Fatal(err)
+		}
+		if res.Id != "s" || res.Type != FrameChunk || !res.Status || len(chunk) != 1 || chunk[0] != i {
+			t.Fatalf("got %+v, want chunk %d", res, i)
+		}
+	}
+	if res := tc.mustRecv(t); res.Id != "s" || res.Type != FrameEnd || !res.Status || len(res.Data) != 0 {
+		t.Fatalf("got %+v, want a successful end frame", res)
+	}
+
+	tc.send(t, "c", StreamClasses, nil)
+	if res := tc.mustRecv(t); res.Type != FrameChunk {
+		t.Fatalf("got %+v, want a chunk", res)
+	}
+	if res := tc.mustRecv(t); res.Id != "c" || res.Type != FrameEnd || res.Status || res.Code != string(CodeNotFound) {
+		t.Fatalf("got %+v, want an end frame with not_found", res)
+	}
+
+	// a stream sent as a plain request in a batch can't stream
+	tc.send(t, "b", Batch, dto.BatchReq{Requests: []dto.Request{{Id: "1", Type: string(StreamSchools)}}})
+	if res := tc.mustRecv(t); res.Id != "b" || res.Type == FrameChunk {
+		t.Fatalf("got %+v, want the batch reply", res)
+	}
+}
+
+func TestStreamStopsWhenClientStopsReading(t *testing.T) {
+	cfg := testCfg()
+	cfg.WriteTimeout = 50 * time.Millisecond
+	s := newTestServer(cfg)
+
+	stopped := make(chan error, 1)
+	s.RegisterStreamHandler(StreamSchools, func(ctx context.Context, payload Payload, send func(chunk interface{}) error) error {
+		for {
+			if err := send([]string{"school"}); err != nil {
+				stopped <- err
+				return err
+			}
+		}
+	})
+	tc := serveTestConn(t, s)
+
+	tc.send(t, "1", StreamSchools, nil)
+	// read a chunk, then nothing more
+	if res := tc.mustRecv(t); res.Type != FrameChunk {
+		t.Fatalf("got %+v, want a chunk", res)
+	}
+
+	select {
+	case err := <-stopped:
+		if err == nil {
+			t.Fatal("send failed without an error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream kept running after the client stopped reading")
+	}
+}
+
+func TestClientStreamBreakEarly(t *testing.T) {
+	s := newTestServer(testCfg())
+	s.RegisterStreamHandler(StreamSchools, func(ctx context.Context, payload Payload, send func(chunk interface{}) error) error {
+		for i := 0; i < 100; i++ {
+			if err := send([]int{i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		return "listed", nil
+	})
+
+	c := newTestClient(startTestServer(t, s))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	var got []int
+	for i, err := range StreamItems[int](ctx, c, StreamSchools, nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, i)
+		if len(got) == 2 {
+			break
+		}
+	}
+	if len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Fatalf("got %v, want the first two items", got)
+	}
+
+	// the chunks after the break are dropped, not left in the way
+	res, err := c.Send(ctx, ListSchools, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data string
+	if err := c.Decode(res, &data); err != nil || data != "listed" {
+		t.Fatalf("got %q, %v after the stream, want the list reply", data, err)
+	}
+}
@@ -0,0 +1,207 @@
+package tcp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates signed by a throwaway CA.
+type testCA struct {
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	// the PEM file of the CA certificate
+	file string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	ca := &testCA{dir: t.TempDir()}
+	ca.cert, ca.key, ca.file = ca.issue(t, "ca", &x509.Certificate{
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	})
+	return ca
+}
+
+// issue signs tmpl with the CA, or with its own key while the CA has
+// none yet, and writes it to name.crt.
+func (ca *testCA) issue(t *testing.T, name string, tmpl *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.Subject = pkix.Name{CommonName: name}
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+
+	parent, signer := tmpl, key
+	if ca.cert != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(ca.dir, name+".crt")
+	writePEM(t, file, "CERTIFICATE", der)
+	return cert, key, file
+}
+
+// pair issues a certificate for name and returns its certificate and key
+// files.
+func (ca *testCA) pair(t *testing.T, name string, usage x509.ExtKeyUsage) (string, string) {
+	t.Helper()
+
+	_, key, certFile := ca.issue(t, name, &x509.Certificate{
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+	})
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(ca.dir, name+".key")
+	writePEM(t, keyFile, "EC PRIVATE KEY", der)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, file, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTLSServer starts a server with tlsCfg whose list_schools handler
+// replies with the common name of the client certificate.
+func newTLSServer(t *testing.T, tlsCfg *TLSConfig) string {
+	t.Helper()
+
+	cfg := testCfg()
+	cfg.TLS = tlsCfg
+	s := newTestServer(cfg)
+	s.RegisterHandler(ListSchools, func(ctx context.Context, payload Payload) (interface{}, error) {
+		subject, _ := ClientCertSubject(ctx)
+		return subject.CommonName, nil
+	})
+	return startTestServer(t, s)
+}
+
+// tlsRoundTrip connects with tlsCfg and returns the common name the
+// server saw, or the error of the first step that failed.
+func tlsRoundTrip(addr string, tlsCfg *TLSConfig) (string, error) {
+	c := NewClient(WithClientCfg(ClientConfig{
+		Network:        "tcp",
+		Address:        addr,
+		Framing:        NewlineFraming,
+		ReadTimeout:    time.Second,
+		WriteTimeout:   time.Second,
+		ConnectTimeout: time.Second,
+		TLS:            tlsCfg,
+	}))
+	if err := c.Connect(); err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	res, err := c.Send(context.Background(), ListSchools, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := ResponseError(res); err != nil {
+		return "", err
+	}
+	var name string
+	err = c.Decode(res, &name)
+	return name, err
+}
+
+func TestTLS(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.pair(t, "server", x509.ExtKeyUsageServerAuth)
+	addr := newTLSServer(t, &TLSConfig{CertFile: certFile, KeyFile: keyFile})
+
+	name, err := tlsRoundTrip(addr, &TLSConfig{CAFile: ca.file})
+	if err != nil {
+		t.Fatalf("TLS client: %v", err)
+	}
+	if name != "" {
+		t.Fatalf("server saw client certificate %q, want none", name)
+	}
+
+	if _, err := tlsRoundTrip(addr, nil); err == nil {
+		t.Fatal("plain client got through to a TLS server")
+	}
+
+	other := newTestCA(t)
+	if _, err := tlsRoundTrip(addr, &TLSConfig{CAFile: other.file}); err == nil {
+		t.Fatal("client trusted a server certificate of another CA")
+	}
+	if _, err := tlsRoundTrip(addr, &TLSConfig{CAFile: ca.file, ServerName: "example.com"}); err == nil {
+		t.Fatal("client accepted a certificate for another name")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.pair(t, "server", x509.ExtKeyUsageServerAuth)
+	addr := newTLSServer(t, &TLSConfig{
+		CertFile:          certFile,
+		KeyFile:           keyFile,
+		CAFile:            ca.file,
+		RequireClientCert: true,
+	})
+
+	clientCert, clientKey := ca.pair(t, "alice", x509.ExtKeyUsageClientAuth)
+	name, err := tlsRoundTrip(addr, &TLSConfig{CAFile: ca.file, CertFile: clientCert, KeyFile: clientKey})
+	if err != nil {
+		t.Fatalf("client with a certificate: %v", err)
+	}
+	if name != "alice" {
+		t.Fatalf("server saw client certificate %q, want alice", name)
+	}
+
+	if _, err := tlsRoundTrip(addr, &TLSConfig{CAFile: ca.file}); err == nil {
+		t.Fatal("client without a certificate got through")
+	}
+
+	other := newTestCA(t)
+	otherCert, otherKey := other.pair(t, "mallory", x509.ExtKeyUsageClientAuth)
+	if _, err := tlsRoundTrip(addr, &TLSConfig{CAFile: ca.file, CertFile: otherCert, KeyFile: otherKey}); err == nil {
+		t.Fatal("client with a certificate of another CA got through")
+	}
+}
+
+func TestTLSConfigRequiresCAForClientCerts(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.pair(t, "server", x509.ExtKeyUsageServerAuth)
+
+	cfg := &TLSConfig{CertFile: certFile, KeyFile: keyFile, RequireClientCert: true}
+	if _, err := cfg.serverConfig(); err == nil {
+		t.Fatal("mutual TLS without a CA file was accepted")
+	}
+}
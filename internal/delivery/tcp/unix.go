@@ -0,0 +1,144 @@
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnixSocketConfig applies to listeners on the unix network. Abstract
+// sockets (addresses starting with "@") have no file, so only peer
+// credentials apply to them.
+type UnixSocketConfig struct {
+	// Mode is applied to the socket file, zero keeps the umask default.
+	Mode os.FileMode
+	// User and Group own the socket file, by name or numeric id. Empty
+	// keeps the owner of the server process.
+	User  string
+	Group string
+}
+
+// PeerCredentials identify the process on the other end of a unix socket.
+type PeerCredentials struct {
+	Pid int32
+	Uid uint32
+	Gid uint32
+}
+
+func isUnixNetwork(network string) bool {
+	return network == "unix" || network == "unixpacket"
+}
+
+func isAbstractSocket(address string) bool {
+	return strings.HasPrefix(address, "@")
+}
+
+func listenUnix(network, address string, cfg UnixSocketConfig) (net.Listener, error) {
+	if isAbstractSocket(address) {
+		return net.Listen(network, address)
+	}
+
+	if err := removeStaleSocket(network, address); err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applySocketPermissions(address, cfg); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// removeStaleSocket deletes a socket file left behind by a server that did
+// not shut down cleanly. A socket that still accepts connections belongs to
+// a running server and is left alone.
+func removeStaleSocket(network, address string) error {
+	info, err := os.Lstat(address)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat socket file: %w", err)
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", address)
+	}
+
+	conn, err := net.DialTimeout(network, address, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", address)
+	}
+
+	if err := os.Remove(address); err != nil {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return nil
+}
+
+func applySocketPermissions(address string, cfg UnixSocketConfig) error {
+	if cfg.Mode != 0 {
+		if err := os.Chmod(address, cfg.Mode); err != nil {
+			return fmt.Errorf("failed to set socket mode: %w", err)
+		}
+	}
+
+	if cfg.User == "" && cfg.Group == "" {
+		return nil
+	}
+
+	uid, gid := -1, -1
+	if cfg.User != "" {
+		id, err := lookupUser(cfg.User)
+		if err != nil {
+			return err
+		}
+		uid = id
+	}
+	if cfg.Group != "" {
+		id, err := lookupGroup(cfg.Group)
+		if err != nil {
+			return err
+		}
+		gid = id
+	}
+
+	if err := os.Chown(address, uid, gid); err != nil {
+		return fmt.Errorf("failed to set socket owner: %w", err)
+	}
+	return nil
+}
+
+func lookupUser(name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up socket user: %w", err)
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+func lookupGroup(name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up socket group: %w", err)
+	}
+	return strconv.Atoi(g.Gid)
+}
@@ -0,0 +1,67 @@
+package tcp
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixSocketFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.sock")
+
+	l, err := listenUnix("unix", path, UnixSocketConfig{Mode: 0o600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Fatalf("socket file has mode %v, want a socket with 0600", info.Mode())
+	}
+
+	// a running server keeps its socket
+	if _, err := listenUnix("unix", path, UnixSocketConfig{}); err == nil {
+		t.Fatal("listened on the socket of a running server")
+	}
+
+	// a crashed one leaves it behind, the next server replaces it
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("socket file is gone, the test needs a stale one: %v", err)
+	}
+	l, err = listenUnix("unix", path, UnixSocketConfig{})
+	if err != nil {
+		t.Fatalf("stale socket wasn't replaced: %v", err)
+	}
+	l.Close()
+}
+
+func TestListenUnixRefusesOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := listenUnix("unix", path, UnixSocketConfig{}); err == nil {
+		t.Fatal("listened over a regular file")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+		t.Fatalf("regular file was touched: %q, %v", data, err)
+	}
+}
+
+func TestLookupSocketOwner(t *testing.T) {
+	if id, err := lookupUser("1234"); err != nil || id != 1234 {
+		t.Fatalf("lookupUser(1234) = %d, %v", id, err)
+	}
+	if id, err := lookupGroup("1234"); err != nil || id != 1234 {
+		t.Fatalf("lookupGroup(1234) = %d, %v", id, err)
+	}
+	if _, err := lookupUser("no-such-user-here"); err == nil {
+		t.Fatal("looked up a user that doesn't exist")
+	}
+}
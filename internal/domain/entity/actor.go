@@ -0,0 +1,22 @@
+package entity
+
+import "context"
+
+// Actor is the person a use case runs on behalf of. Use cases called
+// without one deny everything that takes a role, the command line passes
+// an admin actor.
+type Actor struct {
+	PersonId uint
+	Role     Role
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
@@ -1,9 +1,13 @@
 package entity
 
+import "time"
+
 type Class struct {
 	Id       uint
 	Name     string
 	SchoolId uint
 	Teacher  Person
 	Students []Person
+	// zero unless the class was soft deleted
+	DeletedAt time.Time
 }
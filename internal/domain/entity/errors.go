@@ -7,5 +7,20 @@ var (
 	ErrInvalidPerson = errors.New("invalid person")
 	ErrInvalidSchool = errors.New("invalid school")
 	ErrInvalidClass  = errors.New("invalid class")
-	ErrNotFound      = errors.New("entity not found")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("already exists")
+	// ErrInUse is returned when deleting a record others still refer to
+	ErrInUse = errors.New("still in use")
+	// ErrInvalidCredentials doesn't tell whether the person or the
+	// password was wrong
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrAccountLocked      = errors.New("account locked")
+	ErrInvalidListOptions = errors.New("invalid list options")
+	ErrInvalidQuery       = errors.New("invalid search query")
+	// ErrNotStudent, ErrNotTeacher and ErrSchoolMismatch are returned
+	// when a class would get a member of the wrong role or school
+	ErrNotStudent     = errors.New("not a student")
+	ErrNotTeacher     = errors.New("not a teacher")
+	ErrSchoolMismatch = errors.New("not in the school of the class")
 )
@@ -2,11 +2,15 @@ package entity
 
 // Enterprise Business Rules
 
+import "time"
+
 type Role string
 
 const (
 	StudentRole Role = "student"
-	TeacherRole Role = "Teacher"
+	TeacherRole Role = "teacher"
+	// AdminRole may do anything, admins don't need a school
+	AdminRole Role = "admin"
 )
 
 type Person struct {
@@ -15,4 +19,11 @@ type Person struct {
 	Role    Role
 	School  School
 	Classes []uint
+	// bcrypt hash, empty for persons that can't log in
+	PasswordHash string
+	// failed logins in a row, logins fail until LockedUntil
+	FailedLogins int
+	LockedUntil  time.Time
+	// zero unless the person was soft deleted
+	DeletedAt time.Time
 }
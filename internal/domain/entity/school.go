@@ -1,7 +1,11 @@
 package entity
 
+import "time"
+
 type School struct {
 	Id      uint
 	Name    string
 	Classes []Class
+	// zero unless the school was soft deleted
+	DeletedAt time.Time
 }
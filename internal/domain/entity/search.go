@@ -0,0 +1,26 @@
+package entity
+
+// SearchKind is the type of record a search result points at.
+type SearchKind string
+
+const (
+	SchoolKind SearchKind = "school"
+	PersonKind SearchKind = "person"
+	ClassKind  SearchKind = "class"
+)
+
+func (k SearchKind) IsValid() bool {
+	switch k {
+	case SchoolKind, PersonKind, ClassKind:
+		return true
+	}
+	return false
+}
+
+type SearchResult struct {
+	Kind SearchKind
+	Id   uint
+	Name string
+	// Rank orders results across kinds, lower ranks match better
+	Rank float64
+}
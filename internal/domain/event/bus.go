@@ -0,0 +1,56 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bus is an in-memory Publisher and Subscriber. Handlers are called
+// synchronously by Publish, so they must not block.
+type Bus struct {
+	mu       sync.RWMutex
+	nextId   uint64
+	handlers map[uint64]func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[uint64]func(Event)),
+	}
+}
+
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	if deferPublish(ctx, func() { b.publish(e) }) {
+		return
+	}
+	b.publish(e)
+}
+
+func (b *Bus) publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, h := range b.handlers {
+		h(e)
+	}
+}
+
+func (b *Bus) Subscribe(handler func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextId++
+	id := b.nextId
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBusPublish(t *testing.T) {
+	bus := NewBus()
+
+	var got []Event
+	unsubscribe := bus.Subscribe(func(e Event) { got = append(got, e) })
+
+	bus.Publish(context.Background(), Event{Type: SchoolCreated, SchoolId: 1})
+	if len(got) != 1 || got[0].SchoolId != 1 || got[0].OccurredAt.IsZero() {
+		t.Fatalf("got %+v, want the event with its time set", got)
+	}
+
+	unsubscribe()
+	bus.Publish(context.Background(), Event{Type: SchoolCreated, SchoolId: 2})
+	if len(got) != 1 {
+		t.Fatalf("got %+v after unsubscribing", got)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	bus := NewBus()
+
+	var got []uint
+	bus.Subscribe(func(e Event) { got = append(got, e.SchoolId) })
+
+	ctx, release := Defer(context.Background())
+	bus.Publish(ctx, Event{Type: SchoolCreated, SchoolId: 1})
+	bus.Publish(ctx, Event{Type: SchoolUpdated, SchoolId: 2})
+	// only the deferred ctx is held back
+	bus.Publish(context.Background(), Event{Type: SchoolCreated, SchoolId: 3})
+	if len(got) != 1 || got[0] != 3 {
+		t.Fatalf("got %v before release, want only the event outside the deferred ctx", got)
+	}
+
+	release()
+	if len(got) != 3 || got[1] != 1 || got[2] != 2 {
+		t.Fatalf("got %v after release, want the held events in order", got)
+	}
+
+	release()
+	if len(got) != 3 {
+		t.Fatalf("got %v, a second release published again", got)
+	}
+
+	// a ctx that is never released drops its events
+	ctx, _ = Defer(context.Background())
+	bus.Publish(ctx, Event{Type: SchoolCreated, SchoolId: 4})
+	if len(got) != 3 {
+		t.Fatalf("got %v, an unreleased event was published", got)
+	}
+}
@@ -0,0 +1,45 @@
+package event
+
+import (
+	"context"
+	"sync"
+)
+
+type deferredKey struct{}
+
+type deferred struct {
+	mu      sync.Mutex
+	pending []func()
+}
+
+// Defer holds back events published with the returned ctx until release
+// is called, e.g. once a transaction commits. Events of a ctx that is
+// never released are dropped.
+func Defer(ctx context.Context) (context.Context, func()) {
+	d := &deferred{}
+	release := func() {
+		d.mu.Lock()
+		pending := d.pending
+		d.pending = nil
+		d.mu.Unlock()
+
+		for _, publish := range pending {
+			publish()
+		}
+	}
+	return context.WithValue(ctx, deferredKey{}, d), release
+}
+
+// deferPublish queues publish if ctx is deferred and reports whether it
+// did.
+func deferPublish(ctx context.Context, publish func()) bool {
+	d, ok := ctx.Value(deferredKey{}).(*deferred)
+	if !ok {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending = append(d.pending, publish)
+	return true
+}
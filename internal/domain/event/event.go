@@ -0,0 +1,56 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+type Type string
+
+const (
+	SchoolCreated       Type = "school_created"
+	PersonCreated       Type = "person_created"
+	ClassCreated        Type = "class_created"
+	StudentAddedToClass Type = "student_added_to_class"
+	SchoolUpdated       Type = "school_updated"
+	SchoolDeleted       Type = "school_deleted"
+	PersonUpdated       Type = "person_updated"
+	PersonDeleted       Type = "person_deleted"
+	ClassUpdated        Type = "class_updated"
+	ClassDeleted        Type = "class_deleted"
+	SchoolRestored      Type = "school_restored"
+	PersonRestored      Type = "person_restored"
+	ClassRestored       Type = "class_restored"
+)
+
+// Types returns every event type use cases publish.
+func Types() []Type {
+	return []Type{
+		SchoolCreated, PersonCreated, ClassCreated, StudentAddedToClass,
+		SchoolUpdated, SchoolDeleted, PersonUpdated, PersonDeleted,
+		ClassUpdated, ClassDeleted, SchoolRestored, PersonRestored,
+		ClassRestored,
+	}
+}
+
+// Event records a change to an aggregate. It only carries the ids
+// involved, subscribers load whatever else they need.
+type Event struct {
+	Type       Type
+	OccurredAt time.Time
+	SchoolId   uint
+	PersonId   uint
+	ClassId    uint
+}
+
+type Publisher interface {
+	// Publish sends e to subscribers, or holds it back if ctx comes from
+	// Defer.
+	Publish(ctx context.Context, e Event)
+}
+
+type Subscriber interface {
+	// Subscribe calls handler for every published event until the returned
+	// func is called.
+	Subscribe(handler func(Event)) (unsubscribe func())
+}
@@ -1,10 +1,32 @@
 package repository
 
-import "github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
 
 type ClassRepository interface {
-	CreateClass(name string, schoolId, teacherId uint) uint
-	GetClassByID(id uint) (*entity.Class, error)
-	GetAllClasses() (*[]entity.Class, error)
-	AddStudentToClass(classId, studentId uint) error
+	CreateClass(ctx context.Context, name string, schoolId, teacherId uint) (uint, error)
+	GetClassByID(ctx context.Context, id uint) (*entity.Class, error)
+	// GetAllClasses returns a page of classes and the cursor of the next
+	// one, which is empty after the last page.
+	GetAllClasses(ctx context.Context, opts ClassListOptions) (*[]entity.Class, string, error)
+	// GetClassesInBatches calls fn with every class, at most batchSize
+	// at a time, and stops at the first error fn returns.
+	GetClassesInBatches(ctx context.Context, opts ClassListOptions, batchSize int, fn func(*[]entity.Class) error) error
+	AddStudentToClass(ctx context.Context, classId, studentId uint) error
+	// UpdateClass changes the name and teacher of a class, zero values
+	// are left unchanged.
+	UpdateClass(ctx context.Context, id uint, name string, teacherId uint) error
+	// DeleteClass soft deletes a class, its students stay enrolled until
+	// it is purged.
+	DeleteClass(ctx context.Context, id uint) error
+	// RestoreClass fails with ErrNotFound while the school or teacher of
+	// the class is deleted.
+	RestoreClass(ctx context.Context, id uint) error
+	// PurgeClasses permanently removes the classes soft deleted before
+	// the given time.
+	PurgeClasses(ctx context.Context, before time.Time) (int64, error)
 }
@@ -0,0 +1,34 @@
+package repository
+
+import "github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+
+// ListOptions narrows down, sorts and pages the records the GetAll*
+// methods return. The Get*InBatches methods page through every record
+// after Cursor themselves, in batches of their own size instead of Limit.
+type ListOptions struct {
+	// IncludeDeleted also returns soft deleted records
+	IncludeDeleted bool
+	// NamePrefix only keeps the records whose name starts with it
+	NamePrefix string
+	// Sort is id or name, prefixed with "-" for descending order. Ties
+	// are broken by id, which is also the default.
+	Sort string
+	// Limit is the page size, zero returns every record
+	Limit int
+	// Cursor continues after the page it was returned with
+	Cursor string
+}
+
+type PersonListOptions struct {
+	ListOptions
+	Role     entity.Role
+	SchoolId uint
+}
+
+type ClassListOptions struct {
+	ListOptions
+	SchoolId  uint
+	TeacherId uint
+	// StudentId only keeps the classes the student is enrolled in
+	StudentId uint
+}
@@ -1,11 +1,40 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
 )
 
 type PersonRepositroy interface {
-	CreatePerson(person *entity.Person) uint
-	GetPersonByID(personId uint) (*entity.Person, error)
-	GetAllPersons() (*[]entity.Person, error)
+	CreatePerson(ctx context.Context, person *entity.Person) (uint, error)
+	GetPersonByID(ctx context.Context, personId uint) (*entity.Person, error)
+	// GetAllPersons returns a page of persons and the cursor of the next
+	// one, which is empty after the last page.
+	GetAllPersons(ctx context.Context, opts PersonListOptions) (*[]entity.Person, string, error)
+	// GetPersonsInBatches calls fn with every person, at most batchSize
+	// at a time, and stops at the first error fn returns.
+	GetPersonsInBatches(ctx context.Context, opts PersonListOptions, batchSize int, fn func(*[]entity.Person) error) error
+	// SetPasswordHash replaces the password of the person and unlocks it.
+	SetPasswordHash(ctx context.Context, personId uint, hash string) error
+	// RecordFailedLogin returns the failed logins in a row including
+	// this one.
+	RecordFailedLogin(ctx context.Context, personId uint) (int, error)
+	// LockPerson stops logins until the given time.
+	LockPerson(ctx context.Context, personId uint, until time.Time) error
+	ResetFailedLogins(ctx context.Context, personId uint) error
+	// UpdatePerson changes the name and school of a person, zero values
+	// are left unchanged.
+	UpdatePerson(ctx context.Context, personId uint, name string, schoolId uint) error
+	// DeletePerson soft deletes a person, it fails with ErrInUse while
+	// they teach a class.
+	DeletePerson(ctx context.Context, personId uint) error
+	// RestorePerson fails with ErrNotFound while the school of the
+	// person is deleted.
+	RestorePerson(ctx context.Context, personId uint) error
+	// PurgePersons permanently removes the persons soft deleted before
+	// the given time, and takes them out of their classes. Teachers of
+	// classes that are not purged yet are kept.
+	PurgePersons(ctx context.Context, before time.Time) (int64, error)
 }
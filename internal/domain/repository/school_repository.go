@@ -1,10 +1,28 @@
 package repository
 
-import "github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
 
 type SchoolRepository interface {
-	CreateSchool(name string) uint
-	GetSchoolByID(id uint) (*entity.School, error)
-	GetSchoolByName(schoolName string) (*entity.School, error)
-	GetAllSchools() (*[]entity.School, error)
+	CreateSchool(ctx context.Context, name string) (uint, error)
+	GetSchoolByID(ctx context.Context, id uint) (*entity.School, error)
+	GetSchoolByName(ctx context.Context, schoolName string) (*entity.School, error)
+	// GetAllSchools returns a page of schools and the cursor of the next
+	// one, which is empty after the last page.
+	GetAllSchools(ctx context.Context, opts ListOptions) (*[]entity.School, string, error)
+	// GetSchoolsInBatches calls fn with every school, at most batchSize
+	// at a time, and stops at the first error fn returns.
+	GetSchoolsInBatches(ctx context.Context, opts ListOptions, batchSize int, fn func(*[]entity.School) error) error
+	UpdateSchool(ctx context.Context, id uint, name string) error
+	// DeleteSchool fails with ErrInUse while persons or classes belong
+	// to the school.
+	DeleteSchool(ctx context.Context, id uint) error
+	RestoreSchool(ctx context.Context, id uint) error
+	// PurgeSchools permanently removes the schools soft deleted before
+	// the given time that no person or class refers to anymore.
+	PurgeSchools(ctx context.Context, before time.Time) (int64, error)
 }
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+)
+
+type SearchOptions struct {
+	// Kinds limits the results to these kinds, empty searches them all
+	Kinds []entity.SearchKind
+	// Limit is the most results returned
+	Limit int
+}
+
+type SearchRepository interface {
+	// Search returns the records whose names start with every word of
+	// query, best match first. Soft deleted records are left out.
+	Search(ctx context.Context, query string, opts SearchOptions) (*[]entity.SearchResult, error)
+}
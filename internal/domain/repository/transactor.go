@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+type Transactor interface {
+	// WithinTransaction runs fn in a transaction that is committed when fn
+	// returns nil and rolled back otherwise. Repositories called with the
+	// ctx passed to fn take part in it.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -21,6 +21,8 @@ func ClassToEntity(c *model.Class) *entity.Class {
 		SchoolId: c.SchoolID,
 		Teacher:  *PersonToEntity(&c.Teacher),
 		Students: students,
+
+		DeletedAt: c.DeletedAt.Time,
 	}
 }
 
@@ -10,10 +10,19 @@ func PersonToEntity(p *model.Person) *entity.Person {
 		return nil
 	}
 
-	return &entity.Person{
+	person := &entity.Person{
 		Id:     p.ID,
 		Name:   p.Name,
 		Role:   entity.Role(p.Role),
 		School: *SchoolToEntity(&p.School),
+
+		PasswordHash: p.PasswordHash,
+		FailedLogins: p.FailedLogins,
+
+		DeletedAt: p.DeletedAt.Time,
+	}
+	if p.LockedUntil != nil {
+		person.LockedUntil = *p.LockedUntil
 	}
+	return person
 }
@@ -11,8 +11,9 @@ func SchoolToEntity(s *model.School) *entity.School {
 	}
 
 	return &entity.School{
-		Id:   s.ID,
-		Name: s.Name,
+		Id:        s.ID,
+		Name:      s.Name,
+		DeletedAt: s.DeletedAt.Time,
 	}
 }
 
@@ -1,79 +1,247 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/repository/mapper"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/repository/sqlite/model"
 	"gorm.io/gorm"
 )
 
 func (s *sqlit) CreateClass(
+	ctx context.Context,
 	name string,
 	schoolId, teacherId uint,
-) uint {
+) (uint, error) {
+	if err := s.conn(ctx).First(&model.School{}, schoolId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("school %d: %w", schoolId, entity.ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get school by id: %w", err)
+	}
+
+	if err := s.conn(ctx).First(&model.Person{}, teacherId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("teacher %d: %w", teacherId, entity.ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get teacher by id: %w", err)
+	}
+
 	var class = model.Class{
 		Name:      name,
 		TeacherID: teacherId,
 		SchoolID:  schoolId,
 	}
 
-	s.db.
+	err := s.conn(ctx).
 		FirstOrCreate(
 			&class,
 			model.Class{
 				Name:      class.Name,
 				SchoolID:  class.SchoolID,
 				TeacherID: class.TeacherID,
-			})
-	return class.ID
+			}).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to create class: %w", err)
+	}
+	return class.ID, nil
 }
 
-func (s *sqlit) GetClassByID(id uint) (*entity.Class, error) {
+func (s *sqlit) GetClassByID(ctx context.Context, id uint) (*entity.Class, error) {
 	var class model.Class
-	err := s.db.
+	err := s.conn(ctx).
 		Preload("School").
 		Preload("Teacher").
 		Preload("Students").
 		First(&class, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("class not found: %w", err)
+			return nil, fmt.Errorf("class %d: %w", id, entity.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get class by id: %w", err)
 	}
 	return mapper.ClassToEntity(&class), nil
 }
 
-func (s *sqlit) GetAllClasses() (*[]entity.Class, error) {
+func (s *sqlit) GetAllClasses(ctx context.Context, opts repository.ClassListOptions) (*[]entity.Class, string, error) {
+	db, err := pageQuery(s.conn(ctx).Scopes(classScope(opts)), opts.ListOptions)
+	if err != nil {
+		return nil, "", err
+	}
+
 	var classes []model.Class
-	err := s.db.
+	err = db.
 		Preload("School").
 		Preload("Teacher").
 		Preload("Students").
 		Find(&classes).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to get classes: %w", err)
+		return nil, "", fmt.Errorf("failed to get classes: %w", err)
+	}
+
+	classes, next := nextCursor(classes, opts.ListOptions, func(c model.Class) (string, uint) {
+		return c.Name, c.ID
+	})
+	return mapper.ClassesToEntities(classes), next, nil
+}
+
+func (s *sqlit) GetClassesInBatches(ctx context.Context, opts repository.ClassListOptions, batchSize int, fn func(*[]entity.Class) error) error {
+	return inBatches(opts.ListOptions, batchSize, func(page repository.ListOptions) (*[]entity.Class, string, error) {
+		opts.ListOptions = page
+		return s.GetAllClasses(ctx, opts)
+	}, fn)
+}
+
+func classScope(opts repository.ClassListOptions) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(listScope(opts.ListOptions))
+		if opts.SchoolId != 0 {
+			db = db.Where("school_id = ?", opts.SchoolId)
+		}
+		if opts.TeacherId != 0 {
+			db = db.Where("teacher_id = ?", opts.TeacherId)
+		}
+		if opts.StudentId != 0 {
+			db = db.Where("id IN (SELECT class_id FROM class_students WHERE person_id = ?)", opts.StudentId)
+		}
+		return db
 	}
-	return mapper.ClassesToEntities(classes), nil
 }
 
-func (s *sqlit) AddStudentToClass(classId, studentId uint) error {
+func (s *sqlit) AddStudentToClass(ctx context.Context, classId, studentId uint) error {
 	var class model.Class
-	if err := s.db.First(&class, classId).Error; err != nil {
-		return fmt.Errorf("class not found: %w", err)
+	if err := s.conn(ctx).First(&class, classId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("class %d: %w", classId, entity.ErrNotFound)
+		}
+		return fmt.Errorf("failed to get class by id: %w", err)
 	}
 
 	var student model.Person
-	if err := s.db.First(&student, studentId).Error; err != nil {
-		return fmt.Errorf("student not found: %w", err)
+	if err := s.conn(ctx).First(&student, studentId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("student %d: %w", studentId, entity.ErrNotFound)
+		}
+		return fmt.Errorf("failed to get student by id: %w", err)
+	}
+
+	enrolled := s.conn(ctx).Model(&class).Where("id = ?", studentId).Association("Students").Count()
+	if enrolled > 0 {
+		return fmt.Errorf("student %d in class %d: %w", studentId, classId, entity.ErrConflict)
 	}
 
-	if err := s.db.Model(&class).Association("Students").Append(&student); err != nil {
+	if err := s.conn(ctx).Model(&class).Association("Students").Append(&student); err != nil {
 		return fmt.Errorf("failed to add student to class: %w", err)
 	}
 
 	return nil
 }
+
+func (s *sqlit) UpdateClass(ctx context.Context, classId uint, name string, teacherId uint) error {
+	updates := map[string]interface{}{}
+	if name != "" {
+		updates["name"] = name
+	}
+	if teacherId != 0 {
+		if err := s.conn(ctx).First(&model.Person{}, teacherId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("teacher %d: %w", teacherId, entity.ErrNotFound)
+			}
+			return fmt.Errorf("failed to get teacher by id: %w", err)
+		}
+		updates["teacher_id"] = teacherId
+	}
+
+	res := s.conn(ctx).
+		Model(&model.Class{}).
+		Where("id = ?", classId).
+		Updates(updates)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update class: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("class %d: %w", classId, entity.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *sqlit) DeleteClass(ctx context.Context, classId uint) error {
+	res := s.conn(ctx).Delete(&model.Class{}, classId)
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete class: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("class %d: %w", classId, entity.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *sqlit) RestoreClass(ctx context.Context, classId uint) error {
+	return s.WithinTransaction(ctx, func(ctx context.Context) error {
+		var class model.Class
+		err := s.conn(ctx).
+			Unscoped().
+			Where("deleted_at IS NOT NULL").
+			First(&class, classId).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("deleted class %d: %w", classId, entity.ErrNotFound)
+			}
+			return fmt.Errorf("failed to get class by id: %w", err)
+		}
+
+		if err := s.conn(ctx).First(&model.School{}, class.SchoolID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("school %d of class %d: %w", class.SchoolID, classId, entity.ErrNotFound)
+			}
+			return fmt.Errorf("failed to get school by id: %w", err)
+		}
+		if err := s.conn(ctx).First(&model.Person{}, class.TeacherID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("teacher %d of class %d: %w", class.TeacherID, classId, entity.ErrNotFound)
+			}
+			return fmt.Errorf("failed to get teacher by id: %w", err)
+		}
+
+		err = s.conn(ctx).
+			Unscoped().
+			Model(&class).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return fmt.Errorf("failed to restore class: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *sqlit) PurgeClasses(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := s.WithinTransaction(ctx, func(ctx context.Context) error {
+		classIds := s.conn(ctx).
+			Unscoped().
+			Model(&model.Class{}).
+			Select("id").
+			Where("deleted_at < ?", before)
+		err := s.conn(ctx).
+			Exec("DELETE FROM class_students WHERE class_id IN (?)", classIds).Error
+		if err != nil {
+			return err
+		}
+
+		res := s.conn(ctx).
+			Unscoped().
+			Where("deleted_at < ?", before).
+			Delete(&model.Class{})
+		purged = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge classes: %w", err)
+	}
+	return purged, nil
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/repository/sqlite/model"
@@ -14,6 +15,8 @@ type IStore interface {
 	repository.PersonRepositroy
 	repository.SchoolRepository
 	repository.ClassRepository
+	repository.SearchRepository
+	repository.Transactor
 }
 
 type sqlit struct {
@@ -21,10 +24,18 @@ type sqlit struct {
 }
 
 func NewSqlite(dbPath string) (IStore, error) {
+	// wait for the write lock instead of failing while a transaction
+	// holds it
+	dsn := dbPath
+	if !strings.Contains(dsn, "?") {
+		dsn += "?_pragma=busy_timeout(5000)"
+	}
+
 	db, err := gorm.Open(sqlite.Dialector{
-		DSN: dbPath,
+		DSN: dsn,
 	}, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to db: %w", err)
@@ -45,9 +56,22 @@ func NewSqlite(dbPath string) (IStore, error) {
 }
 
 func migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	// databases created before the admin role still check for the old
+	// roles, sqlite can only drop the check by recreating the table
+	m := db.Migrator()
+	if m.HasTable(&model.Person{}) && m.HasConstraint(&model.Person{}, "chk_persons_role") {
+		if err := m.DropConstraint(&model.Person{}, "chk_persons_role"); err != nil {
+			return fmt.Errorf("failed to drop old role check: %w", err)
+		}
+	}
+
+	err := db.AutoMigrate(
 		&model.Person{},
 		&model.School{},
 		&model.Class{},
 	)
+	if err != nil {
+		return err
+	}
+	return migrateSearch(db)
 }
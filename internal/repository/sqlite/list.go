@@ -0,0 +1,146 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// sortColumns maps the fields lists can be sorted by to their column.
+var sortColumns = map[string]string{
+	"id":   "id",
+	"name": "name",
+}
+
+// cursor points at the last record of a page. Name is only set when the
+// page is sorted by name.
+type cursor struct {
+	Sort string `json:"s,omitempty"`
+	Name string `json:"n,omitempty"`
+	Id   uint   `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// listScope applies the options every list shares except sorting and
+// paging.
+func listScope(opts repository.ListOptions) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if opts.IncludeDeleted {
+			db = db.Unscoped()
+		}
+		if opts.NamePrefix != "" {
+			db = db.Where(`name LIKE ? ESCAPE '\'`, escapeLike(opts.NamePrefix)+"%")
+		}
+		return db
+	}
+}
+
+// pageQuery sorts db by opts.Sort and starts it after opts.Cursor. It
+// asks for one record more than the page size so nextCursor can tell
+// whether another page follows.
+func pageQuery(db *gorm.DB, opts repository.ListOptions) (*gorm.DB, error) {
+	field, desc := strings.CutPrefix(opts.Sort, "-")
+	if field == "" {
+		field = "id"
+	}
+	column, ok := sortColumns[field]
+	if !ok {
+		return nil, fmt.Errorf("%w: can't sort by %q", entity.ErrInvalidListOptions, field)
+	}
+	if opts.Limit < 0 {
+		return nil, fmt.Errorf("%w: negative page size", entity.ErrInvalidListOptions)
+	}
+
+	op, dir := ">", "ASC"
+	if desc {
+		op, dir = "<", "DESC"
+	}
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil || c.Sort != opts.Sort {
+			return nil, fmt.Errorf("%w: cursor doesn't belong to this list", entity.ErrInvalidListOptions)
+		}
+		if column == "id" {
+			db = db.Where("id "+op+" ?", c.Id)
+		} else {
+			db = db.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, op),
+				c.Name, c.Name, c.Id)
+		}
+	}
+
+	if column != "id" {
+		db = db.Order(column + " " + dir)
+	}
+	db = db.Order("id " + dir)
+	if opts.Limit > 0 {
+		db = db.Limit(opts.Limit + 1)
+	}
+	return db, nil
+}
+
+// nextCursor cuts the extra record pageQuery asked for off items and
+// returns the cursor of the page after them, or an empty one if there is
+// none. key returns the name and id of an item.
+func nextCursor[T any](items []T, opts repository.ListOptions, key func(T) (string, uint)) ([]T, string) {
+	if opts.Limit <= 0 || len(items) <= opts.Limit {
+		return items, ""
+	}
+
+	items = items[:opts.Limit]
+	name, id := key(items[len(items)-1])
+
+	c := cursor{Sort: opts.Sort, Id: id}
+	if field, _ := strings.CutPrefix(opts.Sort, "-"); field == "name" {
+		c.Name = name
+	}
+	return items, encodeCursor(c)
+}
+
+// inBatches calls fn with every page page returns for opts, batchSize
+// records at a time and following their cursors. Unlike FindInBatches it
+// keeps the order opts.Sort asks for.
+func inBatches[T any](opts repository.ListOptions, batchSize int,
+	page func(repository.ListOptions) (*[]T, string, error), fn func(*[]T) error) error {
+	opts.Limit = max(batchSize, 1)
+	for {
+		items, next, err := page(opts)
+		if err != nil {
+			return err
+		}
+		if len(*items) > 0 {
+			if err := fn(items); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		opts.Cursor = next
+	}
+}
+
+// escapeLike makes the wildcards in s match themselves in a LIKE pattern
+// escaped with a backslash.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestStore(t *testing.T) *sqlit {
+	t.Helper()
+
+	st, err := NewSqlite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := st.(*sqlit)
+	s.db = s.db.Session(&gorm.Session{Logger: logger.Discard})
+
+	sqlDB, _ := s.db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return s
+}
+
+func TestSchoolsInBatches(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"b", "a", "c", "ab", "a_"} {
+		if _, err := s.CreateSchool(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		opts repository.ListOptions
+		want []string
+	}{
+		{repository.ListOptions{}, []string{"b", "a", "c", "ab", "a_"}},
+		{repository.ListOptions{Sort: "-id"}, []string{"a_", "ab", "c", "a", "b"}},
+		{repository.ListOptions{Sort: "name"}, []string{"a", "a_", "ab", "b", "c"}},
+		{repository.ListOptions{Sort: "-name", NamePrefix: "a"}, []string{"ab", "a_", "a"}},
+		{repository.ListOptions{NamePrefix: "a_"}, []string{"a_"}},
+		// batches aren't bounded by a page size
+		{repository.ListOptions{Sort: "name", Limit: 1}, []string{"a", "a_", "ab", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		for _, batchSize := range []int{1, 2, 100} {
+			var got []string
+			var batches int
+			err := s.GetSchoolsInBatches(ctx, tt.opts, batchSize, func(schools *[]entity.School) error {
+				batches++
+				if len(*schools) > batchSize {
+					t.Errorf("%+v: batch of %d schools, want at most %d", tt.opts, len(*schools), batchSize)
+				}
+				for _, school := range *schools {
+					got = append(got, school.Name)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("%+v: %v", tt.opts, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%+v in batches of %d: got %v, want %v", tt.opts, batchSize, got, tt.want)
+			}
+			if want := (len(tt.want) + batchSize - 1) / batchSize; batches != want {
+				t.Errorf("%+v in batches of %d: got %d batches, want %d", tt.opts, batchSize, batches, want)
+			}
+		}
+	}
+}
+
+func TestInBatchesStopsOnError(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b", "c"} {
+		if _, err := s.CreateSchool(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stop := errors.New("stop")
+	var calls int
+	err := s.GetSchoolsInBatches(ctx, repository.ListOptions{}, 1, func(*[]entity.School) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Fatalf("got %v after %d calls, want the error of the first", err, calls)
+	}
+
+	err = s.GetSchoolsInBatches(ctx, repository.ListOptions{Sort: "age"}, 1, func(*[]entity.School) error {
+		return nil
+	})
+	if !errors.Is(err, entity.ErrInvalidListOptions) {
+		t.Fatalf("got %v for an unknown sort, want ErrInvalidListOptions", err)
+	}
+}
+
+func TestClassesOfStudent(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	schoolId, err := s.CreateSchool(ctx, "school")
+	if err != nil {
+		t.Fatal(err)
+	}
+	school := entity.School{Id: schoolId}
+	teacherId, err := s.CreatePerson(ctx, &entity.Person{Name: "t", Role: entity.TeacherRole, School: school})
+	if err != nil {
+		t.Fatal(err)
+	}
+	studentId, err := s.CreatePerson(ctx, &entity.Person{Name: "s", Role: entity.StudentRole, School: school})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var classIds []uint
+	for _, name := range []string{"a", "b", "c"} {
+		id, err := s.CreateClass(ctx, name, schoolId, teacherId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		classIds = append(classIds, id)
+	}
+	for _, id := range []uint{classIds[0], classIds[2]} {
+		if err := s.AddStudentToClass(ctx, id, studentId); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	classes, _, err := s.GetAllClasses(ctx, repository.ClassListOptions{StudentId: studentId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, class := range *classes {
+		got = append(got, class.Name)
+	}
+	if want := []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Fatalf("got classes %v, want %v", got, want)
+	}
+}
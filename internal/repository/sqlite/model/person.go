@@ -1,19 +1,32 @@
 package model
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Role string
 
 const (
 	StudentRole Role = "student"
 	TeacherRole Role = "teacher"
+	AdminRole   Role = "admin"
 )
 
 type Person struct {
 	ID   uint   `gorm:"primaryKey;autoIncrement"`
 	Name string `gorm:"type:varchar(255);not null"`
-	Role Role   `gorm:"not null;check: role IN ('student', 'teacher')"`
+	Role Role   `gorm:"not null;check:chk_persons_roles,role IN ('student', 'teacher', 'admin')"`
 
 	SchoolID *uint  `gorm:"index"`
 	School   School `gorm:"foreignKey:SchoolID"`
+
+	PasswordHash string `gorm:"type:varchar(255)"`
+	FailedLogins int    `gorm:"not null;default:0"`
+	LockedUntil  *time.Time
+
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 func (Person) TableName() string {
@@ -1,34 +1,52 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/repository/mapper"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/repository/sqlite/model"
 	"gorm.io/gorm"
 )
 
-func (s *sqlit) CreatePerson(person *entity.Person) uint {
+func (s *sqlit) CreatePerson(ctx context.Context, person *entity.Person) (uint, error) {
+	if person.School.Id != 0 {
+		if err := s.conn(ctx).First(&model.School{}, person.School.Id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, fmt.Errorf("school %d: %w", person.School.Id, entity.ErrNotFound)
+			}
+			return 0, fmt.Errorf("failed to get school by id: %w", err)
+		}
+	}
+
 	p := model.Person{
-		Name:     person.Name,
-		Role:     model.Role(person.Role),
-		SchoolID: &person.School.Id,
+		Name: person.Name,
+		Role: model.Role(person.Role),
+
+		PasswordHash: person.PasswordHash,
+	}
+	// admins don't need a school
+	if person.School.Id != 0 {
+		p.SchoolID = &person.School.Id
 	}
-	s.db.
-		Create(&p)
-	return p.ID
+	if err := s.conn(ctx).Create(&p).Error; err != nil {
+		return 0, fmt.Errorf("failed to create person: %w", err)
+	}
+	return p.ID, nil
 }
 
-func (s *sqlit) GetPersonByID(personId uint) (*entity.Person, error) {
+func (s *sqlit) GetPersonByID(ctx context.Context, personId uint) (*entity.Person, error) {
 	var person model.Person
-	err := s.db.
+	err := s.conn(ctx).
 		Preload("School").
 		First(&person, personId).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("person not found: %w", err)
+			return nil, fmt.Errorf("person %d: %w", personId, entity.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get person by id: %w", err)
 	}
@@ -36,19 +54,231 @@ func (s *sqlit) GetPersonByID(personId uint) (*entity.Person, error) {
 
 }
 
-func (s *sqlit) GetAllPersons() (*[]entity.Person, error) {
-	var persons []model.Person
-	err := s.db.
-		Preload("School").
-		Find(&persons).Error
+func (s *sqlit) GetAllPersons(ctx context.Context, opts repository.PersonListOptions) (*[]entity.Person, string, error) {
+	db, err := pageQuery(s.conn(ctx).Scopes(personScope(opts)), opts.ListOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get persons: %w", err)
+		return nil, "", err
 	}
 
-	var personToEntities []entity.Person
+	var persons []model.Person
+	if err := db.Preload("School").Find(&persons).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to get persons: %w", err)
+	}
+
+	persons, next := nextCursor(persons, opts.ListOptions, func(p model.Person) (string, uint) {
+		return p.Name, p.ID
+	})
+
+	personToEntities := make([]entity.Person, 0, len(persons))
 	for _, p := range persons {
 		personToEntities = append(personToEntities, *mapper.PersonToEntity(&p))
 	}
 
-	return &personToEntities, nil
+	return &personToEntities, next, nil
+}
+
+func (s *sqlit) GetPersonsInBatches(ctx context.Context, opts repository.PersonListOptions, batchSize int, fn func(*[]entity.Person) error) error {
+	return inBatches(opts.ListOptions, batchSize, func(page repository.ListOptions) (*[]entity.Person, string, error) {
+		opts.ListOptions = page
+		return s.GetAllPersons(ctx, opts)
+	}, fn)
+}
+
+func personScope(opts repository.PersonListOptions) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(listScope(opts.ListOptions))
+		if opts.Role != "" {
+			db = db.Where("role = ?", string(opts.Role))
+		}
+		if opts.SchoolId != 0 {
+			db = db.Where("school_id = ?", opts.SchoolId)
+		}
+		return db
+	}
+}
+
+func (s *sqlit) SetPasswordHash(ctx context.Context, personId uint, hash string) error {
+	res := s.conn(ctx).
+		Model(&model.Person{}).
+		Where("id = ?", personId).
+		Updates(map[string]interface{}{
+			"password_hash": hash,
+			"failed_logins": 0,
+			"locked_until":  nil,
+		})
+	if res.Error != nil {
+		return fmt.Errorf("failed to set password: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("person %d: %w", personId, entity.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *sqlit) RecordFailedLogin(ctx context.Context, personId uint) (int, error) {
+	var failedLogins int
+	err := s.WithinTransaction(ctx, func(ctx context.Context) error {
+		res := s.conn(ctx).
+			Model(&model.Person{}).
+			Where("id = ?", personId).
+			UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("person %d: %w", personId, entity.ErrNotFound)
+		}
+
+		return s.conn(ctx).
+			Model(&model.Person{}).
+			Where("id = ?", personId).
+			Pluck("failed_logins", &failedLogins).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to record failed login: %w", err)
+	}
+	return failedLogins, nil
+}
+
+func (s *sqlit) LockPerson(ctx context.Context, personId uint, until time.Time) error {
+	err := s.conn(ctx).
+		Model(&model.Person{}).
+		Where("id = ?", personId).
+		Updates(map[string]interface{}{
+			"failed_logins": 0,
+			"locked_until":  until,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock person: %w", err)
+	}
+	return nil
+}
+
+func (s *sqlit) ResetFailedLogins(ctx context.Context, personId uint) error {
+	err := s.conn(ctx).
+		Model(&model.Person{}).
+		Where("id = ?", personId).
+		Updates(map[string]interface{}{
+			"failed_logins": 0,
+			"locked_until":  nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to reset failed logins: %w", err)
+	}
+	return nil
+}
+
+func (s *sqlit) UpdatePerson(ctx context.Context, personId uint, name string, schoolId uint) error {
+	updates := map[string]interface{}{}
+	if name != "" {
+		updates["name"] = name
+	}
+	if schoolId != 0 {
+		if err := s.conn(ctx).First(&model.School{}, schoolId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("school %d: %w", schoolId, entity.ErrNotFound)
+			}
+			return fmt.Errorf("failed to get school by id: %w", err)
+		}
+		updates["school_id"] = schoolId
+	}
+
+	res := s.conn(ctx).
+		Model(&model.Person{}).
+		Where("id = ?", personId).
+		Updates(updates)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update person: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("person %d: %w", personId, entity.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *sqlit) DeletePerson(ctx context.Context, personId uint) error {
+	return s.WithinTransaction(ctx, func(ctx context.Context) error {
+		var classes int64
+		err := s.conn(ctx).
+			Model(&model.Class{}).
+			Where("teacher_id = ?", personId).
+			Count(&classes).Error
+		if err != nil {
+			return fmt.Errorf("failed to count classes of teacher: %w", err)
+		}
+		if classes > 0 {
+			return fmt.Errorf("person %d teaches %d classes: %w", personId, classes, entity.ErrInUse)
+		}
+
+		res := s.conn(ctx).Delete(&model.Person{}, personId)
+		if res.Error != nil {
+			return fmt.Errorf("failed to delete person: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("person %d: %w", personId, entity.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+func (s *sqlit) RestorePerson(ctx context.Context, personId uint) error {
+	return s.WithinTransaction(ctx, func(ctx context.Context) error {
+		var person model.Person
+		err := s.conn(ctx).
+			Unscoped().
+			Where("deleted_at IS NOT NULL").
+			First(&person, personId).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("deleted person %d: %w", personId, entity.ErrNotFound)
+			}
+			return fmt.Errorf("failed to get person by id: %w", err)
+		}
+
+		if person.SchoolID != nil {
+			if err := s.conn(ctx).First(&model.School{}, *person.SchoolID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("school %d of person %d: %w", *person.SchoolID, personId, entity.ErrNotFound)
+				}
+				return fmt.Errorf("failed to get school by id: %w", err)
+			}
+		}
+
+		err = s.conn(ctx).
+			Unscoped().
+			Model(&person).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return fmt.Errorf("failed to restore person: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *sqlit) PurgePersons(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := s.WithinTransaction(ctx, func(ctx context.Context) error {
+		personIds := s.conn(ctx).
+			Unscoped().
+			Model(&model.Person{}).
+			Select("id").
+			Where("deleted_at < ?", before).
+			Where("NOT EXISTS (SELECT 1 FROM classes WHERE classes.teacher_id = persons.id)")
+		err := s.conn(ctx).
+			Exec("DELETE FROM class_students WHERE person_id IN (?)", personIds).Error
+		if err != nil {
+			return err
+		}
+
+		res := s.conn(ctx).
+			Unscoped().
+			Where("id IN (?)", personIds).
+			Delete(&model.Person{})
+		purged = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge persons: %w", err)
+	}
+	return purged, nil
 }
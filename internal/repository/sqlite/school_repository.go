@@ -1,52 +1,153 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/repository/mapper"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/repository/sqlite/model"
 	"gorm.io/gorm"
 )
 
-func (s *sqlit) CreateSchool(name string) uint {
+func (s *sqlit) CreateSchool(ctx context.Context, name string) (uint, error) {
 	school := &model.School{}
-	s.db.
+	err := s.conn(ctx).
 		Where(model.School{Name: name}).
-		FirstOrCreate(school)
-	return school.ID
+		FirstOrCreate(school).Error
+	if err != nil {
+		// a deleted school still holds its name
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return 0, fmt.Errorf("school %q: %w", name, entity.ErrConflict)
+		}
+		return 0, fmt.Errorf("failed to create school: %w", err)
+	}
+	return school.ID, nil
 }
 
-func (s *sqlit) GetSchoolByID(schoolId uint) (*entity.School, error) {
+func (s *sqlit) GetSchoolByID(ctx context.Context, schoolId uint) (*entity.School, error) {
 	var school model.School
-	if err := s.db.First(&school, schoolId).Error; err != nil {
+	if err := s.conn(ctx).First(&school, schoolId).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("school not found: %w", err)
+			return nil, fmt.Errorf("school %d: %w", schoolId, entity.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get school by id: %w", err)
 	}
 	return mapper.SchoolToEntity(&school), nil
 }
 
-func (s *sqlit) GetSchoolByName(schoolName string) (*entity.School, error) {
+func (s *sqlit) GetSchoolByName(ctx context.Context, schoolName string) (*entity.School, error) {
 	var school model.School
 
-	if err := s.db.Where("name = ?", schoolName).First(&school).Error; err != nil {
+	if err := s.conn(ctx).Where("name = ?", schoolName).First(&school).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("school not found: %w", err)
+			return nil, fmt.Errorf("school %q: %w", schoolName, entity.ErrNotFound)
 		}
-		return nil, fmt.Errorf("failed to get school by id: %w", err)
+		return nil, fmt.Errorf("failed to get school by name: %w", err)
 	}
 	return mapper.SchoolToEntity(&school), nil
 }
 
-func (s *sqlit) GetAllSchools() (*[]entity.School, error) {
+func (s *sqlit) GetAllSchools(ctx context.Context, opts repository.ListOptions) (*[]entity.School, string, error) {
+	db, err := pageQuery(s.conn(ctx).Scopes(listScope(opts)), opts)
+	if err != nil {
+		return nil, "", err
+	}
+
 	var schools []model.School
+	if err := db.Find(&schools).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to get schools: %w", err)
+	}
+
+	schools, next := nextCursor(schools, opts, func(s model.School) (string, uint) {
+		return s.Name, s.ID
+	})
+	return mapper.SchoolsToEntities(schools), next, nil
+}
+
+func (s *sqlit) GetSchoolsInBatches(ctx context.Context, opts repository.ListOptions, batchSize int, fn func(*[]entity.School) error) error {
+	return inBatches(opts, batchSize, func(opts repository.ListOptions) (*[]entity.School, string, error) {
+		return s.GetAllSchools(ctx, opts)
+	}, fn)
+}
+
+func (s *sqlit) UpdateSchool(ctx context.Context, schoolId uint, name string) error {
+	res := s.conn(ctx).
+		Model(&model.School{}).
+		Where("id = ?", schoolId).
+		Update("name", name)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("school %q: %w", name, entity.ErrConflict)
+		}
+		return fmt.Errorf("failed to update school: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("school %d: %w", schoolId, entity.ErrNotFound)
+	}
+	return nil
+}
 
-	if err := s.db.Find(&schools).Error; err != nil {
-		return nil, fmt.Errorf("failed to get schools: %w", err)
+func (s *sqlit) DeleteSchool(ctx context.Context, schoolId uint) error {
+	return s.WithinTransaction(ctx, func(ctx context.Context) error {
+		var persons, classes int64
+		err := s.conn(ctx).
+			Model(&model.Person{}).
+			Where("school_id = ?", schoolId).
+			Count(&persons).Error
+		if err != nil {
+			return fmt.Errorf("failed to count persons of school: %w", err)
+		}
+		err = s.conn(ctx).
+			Model(&model.Class{}).
+			Where("school_id = ?", schoolId).
+			Count(&classes).Error
+		if err != nil {
+			return fmt.Errorf("failed to count classes of school: %w", err)
+		}
+		if persons > 0 || classes > 0 {
+			return fmt.Errorf("school %d has %d persons and %d classes: %w",
+				schoolId, persons, classes, entity.ErrInUse)
+		}
+
+		res := s.conn(ctx).Delete(&model.School{}, schoolId)
+		if res.Error != nil {
+			return fmt.Errorf("failed to delete school: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("school %d: %w", schoolId, entity.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+func (s *sqlit) RestoreSchool(ctx context.Context, schoolId uint) error {
+	res := s.conn(ctx).
+		Unscoped().
+		Model(&model.School{}).
+		Where("id = ? AND deleted_at IS NOT NULL", schoolId).
+		Update("deleted_at", nil)
+	if res.Error != nil {
+		return fmt.Errorf("failed to restore school: %w", res.Error)
 	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("deleted school %d: %w", schoolId, entity.ErrNotFound)
+	}
+	return nil
+}
 
-	return mapper.SchoolsToEntities(schools), nil
+func (s *sqlit) PurgeSchools(ctx context.Context, before time.Time) (int64, error) {
+	res := s.conn(ctx).
+		Unscoped().
+		Where("deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM persons WHERE persons.school_id = schools.id)").
+		Where("NOT EXISTS (SELECT 1 FROM classes WHERE classes.school_id = schools.id)").
+		Delete(&model.School{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to purge schools: %w", res.Error)
+	}
+	return res.RowsAffected, nil
 }
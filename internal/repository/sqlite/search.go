@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// searchTables are the tables with an FTS5 index on their names, by the
+// kind of record they hold.
+var searchTables = map[entity.SearchKind]string{
+	entity.SchoolKind: "schools",
+	entity.PersonKind: "persons",
+	entity.ClassKind:  "classes",
+}
+
+// migrateSearch creates the FTS5 index of every searchable table and the
+// triggers that keep it in sync. The index is rebuilt from the table when
+// any of them was missing, like on databases created before search or
+// after a migration recreated the table and dropped its triggers.
+func migrateSearch(db *gorm.DB) error {
+	for _, table := range searchTables {
+		fts := table + "_fts"
+
+		var count int64
+		err := db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name IN (?, ?, ?)",
+			fts+"_ai", fts+"_ad", fts+"_au").
+			Scan(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to look up triggers of %s: %w", fts, err)
+		}
+		if count == 3 {
+			continue
+		}
+
+		stmts := []string{
+			fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS %[1]s USING fts5(
+				name, content='%[2]s', content_rowid='id', prefix='2 3')`, fts, table),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_ai AFTER INSERT ON %[2]s BEGIN
+				INSERT INTO %[1]s(rowid, name) VALUES (new.id, new.name);
+			END`, fts, table),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_ad AFTER DELETE ON %[2]s BEGIN
+				INSERT INTO %[1]s(%[1]s, rowid, name) VALUES ('delete', old.id, old.name);
+			END`, fts, table),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_au AFTER UPDATE OF name ON %[2]s BEGIN
+				INSERT INTO %[1]s(%[1]s, rowid, name) VALUES ('delete', old.id, old.name);
+				INSERT INTO %[1]s(rowid, name) VALUES (new.id, new.name);
+			END`, fts, table),
+			fmt.Sprintf(`INSERT INTO %[1]s(%[1]s) VALUES ('rebuild')`, fts),
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, stmt := range stmts {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", fts, err)
+		}
+	}
+	return nil
+}
+
+func (s *sqlit) Search(ctx context.Context, query string, opts repository.SearchOptions) (*[]entity.SearchResult, error) {
+	match := matchQuery(query)
+	if match == "" {
+		return nil, fmt.Errorf("%w: nothing to search for", entity.ErrInvalidQuery)
+	}
+
+	kinds := opts.Kinds
+	if len(kinds) == 0 {
+		kinds = []entity.SearchKind{entity.SchoolKind, entity.PersonKind, entity.ClassKind}
+	}
+
+	var selects []string
+	var args []interface{}
+	for _, kind := range kinds {
+		table, ok := searchTables[kind]
+		if !ok {
+			return nil, fmt.Errorf("%w: can't search %q", entity.ErrInvalidQuery, kind)
+		}
+		selects = append(selects, fmt.Sprintf(`SELECT ? AS kind, t.id AS id, t.name AS name, f.rank AS rank
+			FROM %[1]s_fts AS f JOIN %[1]s AS t ON t.id = f.rowid
+			WHERE %[1]s_fts MATCH ? AND t.deleted_at IS NULL`, table))
+		args = append(args, string(kind), match)
+	}
+
+	sql := strings.Join(selects, " UNION ALL ") + " ORDER BY rank, kind, id"
+	if opts.Limit > 0 {
+		sql += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	var rows []struct {
+		Kind string
+		Id   uint
+		Name string
+		Rank float64
+	}
+	if err := s.conn(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	results := make([]entity.SearchResult, 0, len(rows))
+	for _, r := range rows {
+		results = append(results, entity.SearchResult{
+			Kind: entity.SearchKind(r.Kind),
+			Id:   r.Id,
+			Name: r.Name,
+			Rank: r.Rank,
+		})
+	}
+	return &results, nil
+}
+
+// matchQuery turns every word of query into a quoted prefix match, so
+// FTS5 syntax in it is searched for literally. An empty result means
+// query has no words.
+func matchQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
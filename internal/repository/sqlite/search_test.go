@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+func TestMatchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"  ", ""},
+		{"spring", `"spring"*`},
+		{" spring  field ", `"spring"* "field"*`},
+		{`say "hi"`, `"say"* """hi"""*`},
+		{"a OR b", `"a"* "OR"* "b"*`},
+		{"name:x* -y", `"name:x*"* "-y"*`},
+	}
+
+	for _, tt := range tests {
+		if got := matchQuery(tt.query); got != tt.want {
+			t.Errorf("matchQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	springfield, err := s.CreateSchool(ctx, "Springfield Elementary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shelbyville, err := s.CreateSchool(ctx, "Shelbyville High")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quoted, err := s.CreateSchool(ctx, `The "Quoted" School`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	teacher, err := s.CreatePerson(ctx, &entity.Person{
+		Name:   "Edna Krabappel",
+		Role:   entity.TeacherRole,
+		School: entity.School{Id: springfield},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	class, err := s.CreateClass(ctx, "Spring Term Math", springfield, teacher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteSchool(ctx, shelbyville); err != nil {
+		t.Fatal(err)
+	}
+
+	result := func(kind entity.SearchKind, id uint) string {
+		return fmt.Sprintf("%s %d", kind, id)
+	}
+	tests := []struct {
+		query string
+		opts  repository.SearchOptions
+		want  []string
+	}{
+		{"spring", repository.SearchOptions{}, []string{
+			result(entity.SchoolKind, springfield), result(entity.ClassKind, class),
+		}},
+		{"SPR", repository.SearchOptions{}, []string{
+			result(entity.SchoolKind, springfield), result(entity.ClassKind, class),
+		}},
+		// every word has to match
+		{"spring math", repository.SearchOptions{}, []string{result(entity.ClassKind, class)}},
+		{"spring", repository.SearchOptions{Kinds: []entity.SearchKind{entity.ClassKind}}, []string{
+			result(entity.ClassKind, class),
+		}},
+		{"spring", repository.SearchOptions{Limit: 1}, nil},
+		{"edna", repository.SearchOptions{}, []string{result(entity.PersonKind, teacher)}},
+		{"edna", repository.SearchOptions{Kinds: []entity.SearchKind{entity.SchoolKind}}, nil},
+		// soft deleted records are left out
+		{"shelby", repository.SearchOptions{}, nil},
+		// FTS5 syntax is searched for literally, the tokenizer drops the
+		// punctuation and operators are words like any other
+		{`"quoted`, repository.SearchOptions{}, []string{result(entity.SchoolKind, quoted)}},
+		{"spring*", repository.SearchOptions{}, []string{
+			result(entity.SchoolKind, springfield), result(entity.ClassKind, class),
+		}},
+		{"spring OR shelby", repository.SearchOptions{}, nil},
+		{"name:spring", repository.SearchOptions{}, nil},
+		{"NOT", repository.SearchOptions{}, nil},
+	}
+
+	for _, tt := range tests {
+		results, err := s.Search(ctx, tt.query, tt.opts)
+		if err != nil {
+			t.Errorf("Search(%q, %+v): %v", tt.query, tt.opts, err)
+			continue
+		}
+
+		var got []string
+		for _, r := range *results {
+			got = append(got, result(r.Kind, r.Id))
+		}
+		if tt.opts.Limit > 0 {
+			if len(got) != tt.opts.Limit {
+				t.Errorf("Search(%q, %+v) = %v, want %d results", tt.query, tt.opts, got, tt.opts.Limit)
+			}
+			continue
+		}
+		slices.Sort(got)
+		slices.Sort(tt.want)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q, %+v) = %v, want %v", tt.query, tt.opts, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"", "   "} {
+		if _, err := s.Search(ctx, query, repository.SearchOptions{}); !errors.Is(err, entity.ErrInvalidQuery) {
+			t.Errorf("Search(%q) = %v, want ErrInvalidQuery", query, err)
+		}
+	}
+	if _, err := s.Search(ctx, "spring", repository.SearchOptions{Kinds: []entity.SearchKind{"teachers"}}); !errors.Is(err, entity.ErrInvalidQuery) {
+		t.Errorf("Search of an unknown kind = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestSearchFollowsChanges(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	id, err := s.CreateSchool(ctx, "Springfield Elementary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := func(query string) bool {
+		t.Helper()
+		results, err := s.Search(ctx, query, repository.SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(*results) == 1 && (*results)[0].Id == id
+	}
+
+	if err := s.UpdateSchool(ctx, id, "Capital City High"); err != nil {
+		t.Fatal(err)
+	}
+	if found("spring") || !found("capital") {
+		t.Fatal("renamed school is still indexed by its old name")
+	}
+
+	if err := s.DeleteSchool(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if found("capital") {
+		t.Fatal("deleted school was found")
+	}
+	if err := s.RestoreSchool(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if !found("capital") {
+		t.Fatal("restored school wasn't found")
+	}
+}
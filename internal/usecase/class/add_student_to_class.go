@@ -1,19 +1,52 @@
 package class
 
-import "github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+import (
+	"context"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
 
 type AddStudentToClassUseCase struct {
-	classRepo repository.ClassRepository
+	classRepo  repository.ClassRepository
+	personRepo repository.PersonRepositroy
+	publisher  event.Publisher
 }
 
 func NewAddStudentToClassUseCase(
 	classRepo repository.ClassRepository,
+	personRepo repository.PersonRepositroy,
+	publisher event.Publisher,
 ) *AddStudentToClassUseCase {
 	return &AddStudentToClassUseCase{
-		classRepo: classRepo,
+		classRepo:  classRepo,
+		personRepo: personRepo,
+		publisher:  publisher,
 	}
 }
 
-func (uc *AddStudentToClassUseCase) Execute(classId, studentId uint) error {
-	return uc.classRepo.AddStudentToClass(classId, studentId)
+// Execute enrolls a student of the school of the class in it.
+func (uc *AddStudentToClassUseCase) Execute(ctx context.Context, classId, studentId uint) error {
+	if err := checkTeaches(ctx, uc.classRepo, classId, "add students to classes"); err != nil {
+		return err
+	}
+
+	class, err := uc.classRepo.GetClassByID(ctx, classId)
+	if err != nil {
+		return err
+	}
+	if err := checkStudent(ctx, uc.personRepo, studentId, class.SchoolId); err != nil {
+		return err
+	}
+
+	if err := uc.classRepo.AddStudentToClass(ctx, classId, studentId); err != nil {
+		return err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{
+		Type:     event.StudentAddedToClass,
+		ClassId:  classId,
+		PersonId: studentId,
+	})
+	return nil
 }
@@ -4,16 +4,25 @@ type ClassUsecases struct {
 	CreateUseCase            *CreateClassUseCase
 	ListUseCase              *ListClassesUseCase
 	AddStudentToClassUseCase *AddStudentToClassUseCase
+	UpdateUseCase            *UpdateClassUseCase
+	DeleteUseCase            *DeleteClassUseCase
+	RestoreUseCase           *RestoreClassUseCase
 }
 
 func NewClassUseCases(
 	createUseCase *CreateClassUseCase,
 	listUseCase *ListClassesUseCase,
 	addStudentToClassUseCase *AddStudentToClassUseCase,
+	updateUseCase *UpdateClassUseCase,
+	deleteUseCase *DeleteClassUseCase,
+	restoreUseCase *RestoreClassUseCase,
 ) *ClassUsecases {
 	return &ClassUsecases{
 		CreateUseCase:            createUseCase,
 		ListUseCase:              listUseCase,
 		AddStudentToClassUseCase: addStudentToClassUseCase,
+		UpdateUseCase:            updateUseCase,
+		DeleteUseCase:            deleteUseCase,
+		RestoreUseCase:           restoreUseCase,
 	}
 }
@@ -1,20 +1,59 @@
 package class
 
-import "github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
 
 type CreateClassUseCase struct {
-	classRepo repository.ClassRepository
+	classRepo  repository.ClassRepository
+	personRepo repository.PersonRepositroy
+	publisher  event.Publisher
 }
 
 func NewCreateClassUseCase(
 	classRepo repository.ClassRepository,
+	personRepo repository.PersonRepositroy,
+	publisher event.Publisher,
 ) *CreateClassUseCase {
 	return &CreateClassUseCase{
-		classRepo: classRepo,
+		classRepo:  classRepo,
+		personRepo: personRepo,
+		publisher:  publisher,
 	}
 }
 
-func (uc *CreateClassUseCase) Execute(name string, schoolId, teacherId uint) uint {
-	return uc.classRepo.CreateClass(name, schoolId, teacherId)
-}
+func (uc *CreateClassUseCase) Execute(ctx context.Context, name string, schoolId, teacherId uint) (uint, error) {
+	if strings.TrimSpace(name) == "" {
+		return 0, fmt.Errorf("%w: name is required", entity.ErrInvalidClass)
+	}
+	actor, ok := entity.ActorFromContext(ctx)
+	switch {
+	case !ok || (actor.Role != entity.AdminRole && actor.Role != entity.TeacherRole):
+		return 0, fmt.Errorf("%w: only teachers and admins can create classes", entity.ErrPermissionDenied)
+	case actor.Role == entity.TeacherRole && actor.PersonId != teacherId:
+		return 0, fmt.Errorf("%w: teachers can only create classes they teach", entity.ErrPermissionDenied)
+	}
 
+	if err := checkTeacher(ctx, uc.personRepo, teacherId, schoolId); err != nil {
+		return 0, err
+	}
+
+	classId, err := uc.classRepo.CreateClass(ctx, name, schoolId, teacherId)
+	if err != nil {
+		return 0, err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{
+		Type:     event.ClassCreated,
+		ClassId:  classId,
+		SchoolId: schoolId,
+		PersonId: teacherId,
+	})
+	return classId, nil
+}
@@ -0,0 +1,37 @@
+package class
+
+import (
+	"context"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type DeleteClassUseCase struct {
+	classRepo repository.ClassRepository
+	publisher event.Publisher
+}
+
+func NewDeleteClassUseCase(
+	classRepo repository.ClassRepository,
+	publisher event.Publisher,
+) *DeleteClassUseCase {
+	return &DeleteClassUseCase{
+		classRepo: classRepo,
+		publisher: publisher,
+	}
+}
+
+// Execute deletes a class, teachers can only delete their own.
+func (uc *DeleteClassUseCase) Execute(ctx context.Context, classId uint) error {
+	if err := checkTeaches(ctx, uc.classRepo, classId, "delete classes"); err != nil {
+		return err
+	}
+
+	if err := uc.classRepo.DeleteClass(ctx, classId); err != nil {
+		return err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{Type: event.ClassDeleted, ClassId: classId})
+	return nil
+}
@@ -1,6 +1,9 @@
 package class
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 )
@@ -17,7 +20,29 @@ func NewListClassesUseCase(
 	}
 }
 
-func (uc *ListClassesUseCase) Execute() (*[]entity.Class, error) {
-	return uc.classRepo.GetAllClasses()
+func (uc *ListClassesUseCase) Execute(ctx context.Context, opts repository.ClassListOptions) (*[]entity.Class, string, error) {
+	if err := uc.authorize(ctx, opts); err != nil {
+		return nil, "", err
+	}
+	return uc.classRepo.GetAllClasses(ctx, opts)
+}
+
+// ExecuteInBatches calls fn with the same items as Execute, batchSize at a
+// time, so they don't all have to fit in memory.
+func (uc *ListClassesUseCase) ExecuteInBatches(ctx context.Context, opts repository.ClassListOptions, batchSize int, fn func(*[]entity.Class) error) error {
+	if err := uc.authorize(ctx, opts); err != nil {
+		return err
+	}
+	return uc.classRepo.GetClassesInBatches(ctx, opts, batchSize, fn)
 }
 
+// authorize only lets admins see deleted classes.
+func (uc *ListClassesUseCase) authorize(ctx context.Context, opts repository.ClassListOptions) error {
+	if !opts.IncludeDeleted {
+		return nil
+	}
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can list deleted classes", entity.ErrPermissionDenied)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package class
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+// checkTeacher makes sure the person exists, is a teacher and belongs to
+// the school of the class they would teach.
+func checkTeacher(ctx context.Context, personRepo repository.PersonRepositroy, teacherId, schoolId uint) error {
+	return checkMember(ctx, personRepo, teacherId, schoolId, entity.TeacherRole, entity.ErrNotTeacher)
+}
+
+// checkStudent makes sure the person exists, is a student and belongs to
+// the school of the class they would be enrolled in.
+func checkStudent(ctx context.Context, personRepo repository.PersonRepositroy, studentId, schoolId uint) error {
+	return checkMember(ctx, personRepo, studentId, schoolId, entity.StudentRole, entity.ErrNotStudent)
+}
+
+// checkMembers makes sure the teacher and students of class still belong
+// to its school. Students that were deleted since are skipped, they
+// don't hold the class back.
+func checkMembers(ctx context.Context, personRepo repository.PersonRepositroy, class *entity.Class) error {
+	if err := checkTeacher(ctx, personRepo, class.Teacher.Id, class.SchoolId); err != nil {
+		return err
+	}
+	for _, student := range class.Students {
+		err := checkStudent(ctx, personRepo, student.Id, class.SchoolId)
+		if errors.Is(err, entity.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkMember(
+	ctx context.Context,
+	personRepo repository.PersonRepositroy,
+	personId, schoolId uint,
+	role entity.Role,
+	roleErr error,
+) error {
+	person, err := personRepo.GetPersonByID(ctx, personId)
+	if err != nil {
+		return err
+	}
+	if person.Role != role {
+		return fmt.Errorf("person %d is %s: %w", personId, person.Role, roleErr)
+	}
+	if person.School.Id != schoolId {
+		return fmt.Errorf("%s %d belongs to school %d, class to %d: %w",
+			role, personId, person.School.Id, schoolId, entity.ErrSchoolMismatch)
+	}
+	return nil
+}
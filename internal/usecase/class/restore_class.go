@@ -0,0 +1,57 @@
+package class
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type RestoreClassUseCase struct {
+	classRepo  repository.ClassRepository
+	personRepo repository.PersonRepositroy
+	transactor repository.Transactor
+	publisher  event.Publisher
+}
+
+func NewRestoreClassUseCase(
+	classRepo repository.ClassRepository,
+	personRepo repository.PersonRepositroy,
+	transactor repository.Transactor,
+	publisher event.Publisher,
+) *RestoreClassUseCase {
+	return &RestoreClassUseCase{
+		classRepo:  classRepo,
+		personRepo: personRepo,
+		transactor: transactor,
+		publisher:  publisher,
+	}
+}
+
+// Execute brings back a soft deleted class, only admins may do this. It
+// fails if the teacher or a student moved to another school since, or
+// the teacher was deleted. Deleted students don't stop the restore.
+func (uc *RestoreClassUseCase) Execute(ctx context.Context, classId uint) error {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can restore classes", entity.ErrPermissionDenied)
+	}
+
+	err := uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.classRepo.RestoreClass(ctx, classId); err != nil {
+			return err
+		}
+		class, err := uc.classRepo.GetClassByID(ctx, classId)
+		if err != nil {
+			return err
+		}
+		return checkMembers(ctx, uc.personRepo, class)
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{Type: event.ClassRestored, ClassId: classId})
+	return nil
+}
@@ -0,0 +1,128 @@
+package class
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type memPersons struct {
+	repository.PersonRepositroy
+	persons map[uint]*entity.Person
+}
+
+func (r memPersons) GetPersonByID(ctx context.Context, personId uint) (*entity.Person, error) {
+	p, ok := r.persons[personId]
+	if !ok {
+		return nil, entity.ErrNotFound
+	}
+	return p, nil
+}
+
+// deletedClass restores a single class, which stays deleted unless the
+// transaction it was restored in commits.
+type deletedClass struct {
+	repository.ClassRepository
+	class    entity.Class
+	restored *bool
+}
+
+func (r deletedClass) RestoreClass(ctx context.Context, classId uint) error {
+	if classId != r.class.Id {
+		return entity.ErrNotFound
+	}
+	*r.restored = true
+	return nil
+}
+
+func (r deletedClass) GetClassByID(ctx context.Context, classId uint) (*entity.Class, error) {
+	if classId != r.class.Id || !*r.restored {
+		return nil, entity.ErrNotFound
+	}
+	return &r.class, nil
+}
+
+// rollback undoes the restore when fn fails.
+type rollback struct {
+	restored *bool
+}
+
+func (tx rollback) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	if err != nil {
+		*tx.restored = false
+	}
+	return err
+}
+
+func TestRestoreClassChecksMembers(t *testing.T) {
+	admin := entity.WithActor(context.Background(), entity.Actor{PersonId: 1, Role: entity.AdminRole})
+
+	tests := []struct {
+		name    string
+		teacher entity.Person
+		student entity.Person
+		// the student was soft deleted, GetPersonByID doesn't find them
+		studentDeleted bool
+		err            error
+	}{
+		{
+			name:    "members in the school",
+			teacher: entity.Person{Id: 2, Role: entity.TeacherRole, School: entity.School{Id: 10}},
+			student: entity.Person{Id: 3, Role: entity.StudentRole, School: entity.School{Id: 10}},
+		},
+		{
+			name:    "teacher moved",
+			teacher: entity.Person{Id: 2, Role: entity.TeacherRole, School: entity.School{Id: 20}},
+			student: entity.Person{Id: 3, Role: entity.StudentRole, School: entity.School{Id: 10}},
+			err:     entity.ErrSchoolMismatch,
+		},
+		{
+			name:    "student moved",
+			teacher: entity.Person{Id: 2, Role: entity.TeacherRole, School: entity.School{Id: 10}},
+			student: entity.Person{Id: 3, Role: entity.StudentRole, School: entity.School{Id: 20}},
+			err:     entity.ErrSchoolMismatch,
+		},
+		{
+			name:           "student deleted",
+			teacher:        entity.Person{Id: 2, Role: entity.TeacherRole, School: entity.School{Id: 10}},
+			student:        entity.Person{Id: 3, Role: entity.StudentRole, School: entity.School{Id: 20}},
+			studentDeleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restored := false
+			classes := deletedClass{
+				class: entity.Class{
+					Id:       1,
+					SchoolId: 10,
+					Teacher:  entity.Person{Id: tt.teacher.Id},
+					Students: []entity.Person{{Id: tt.student.Id}},
+				},
+				restored: &restored,
+			}
+			persons := memPersons{persons: map[uint]*entity.Person{
+				tt.teacher.Id: &tt.teacher,
+				tt.student.Id: &tt.student,
+			}}
+			if tt.studentDeleted {
+				delete(persons.persons, tt.student.Id)
+			}
+			uc := NewRestoreClassUseCase(classes, persons, rollback{&restored}, event.NewBus())
+
+			err := uc.Execute(admin, 1)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if want := tt.err == nil; restored != want {
+				t.Fatalf("class restored %v, want %v", restored, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package class
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+// checkTeaches lets admins and the teacher of the class through, action
+// completes the error of everyone else, e.g. "add students to classes".
+func checkTeaches(ctx context.Context, classRepo repository.ClassRepository, classId uint, action string) error {
+	actor, ok := entity.ActorFromContext(ctx)
+	if ok && actor.Role == entity.AdminRole {
+		return nil
+	}
+	if !ok || actor.Role != entity.TeacherRole {
+		return fmt.Errorf("%w: only teachers can %s", entity.ErrPermissionDenied, action)
+	}
+
+	class, err := classRepo.GetClassByID(ctx, classId)
+	if err != nil {
+		return err
+	}
+	if class.Teacher.Id != actor.PersonId {
+		return fmt.Errorf("%w: class %d is not taught by you", entity.ErrPermissionDenied, classId)
+	}
+	return nil
+}
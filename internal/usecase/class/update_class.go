@@ -0,0 +1,74 @@
+package class
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type UpdateClassUseCase struct {
+	classRepo  repository.ClassRepository
+	personRepo repository.PersonRepositroy
+	publisher  event.Publisher
+}
+
+func NewUpdateClassUseCase(
+	classRepo repository.ClassRepository,
+	personRepo repository.PersonRepositroy,
+	publisher event.Publisher,
+) *UpdateClassUseCase {
+	return &UpdateClassUseCase{
+		classRepo:  classRepo,
+		personRepo: personRepo,
+		publisher:  publisher,
+	}
+}
+
+// Execute renames a class or gives it another teacher of its school, a
+// zero name or teacherId is left unchanged. Teachers can only rename
+// their own classes, handing them over is for admins.
+func (uc *UpdateClassUseCase) Execute(ctx context.Context, classId uint, name string, teacherId uint) (*entity.Class, error) {
+	if name == "" && teacherId == 0 {
+		return nil, fmt.Errorf("%w: name or teacher is required", entity.ErrInvalidClass)
+	}
+	if name != "" && strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: name can't be blank", entity.ErrInvalidClass)
+	}
+
+	if err := checkTeaches(ctx, uc.classRepo, classId, "update classes"); err != nil {
+		return nil, err
+	}
+	if actor, ok := entity.ActorFromContext(ctx); ok && actor.Role == entity.TeacherRole &&
+		teacherId != 0 && teacherId != actor.PersonId {
+		return nil, fmt.Errorf("%w: teachers can't hand classes to other teachers", entity.ErrPermissionDenied)
+	}
+	if teacherId != 0 {
+		class, err := uc.classRepo.GetClassByID(ctx, classId)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTeacher(ctx, uc.personRepo, teacherId, class.SchoolId); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.classRepo.UpdateClass(ctx, classId, name, teacherId); err != nil {
+		return nil, err
+	}
+	class, err := uc.classRepo.GetClassByID(ctx, classId)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{
+		Type:     event.ClassUpdated,
+		ClassId:  classId,
+		SchoolId: class.SchoolId,
+		PersonId: class.Teacher.Id,
+	})
+	return class, nil
+}
@@ -0,0 +1,60 @@
+package person
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+// noPersons fails the test through a nil pointer panic if a use case
+// reaches the repository.
+type noPersons struct {
+	repository.PersonRepositroy
+}
+
+func TestAdminOnlyUseCases(t *testing.T) {
+	repo := noPersons{}
+	bus := event.NewBus()
+
+	calls := map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := NewCreatePersonUseCase(repo, bus).Execute(ctx,
+				entity.Person{Name: "root", Role: entity.AdminRole}, "password1")
+			return err
+		},
+		"set password": func(ctx context.Context) error {
+			return NewSetPasswordUseCase(repo).Execute(ctx, 1, "password1")
+		},
+		"reset password": func(ctx context.Context) error {
+			return NewResetPasswordUseCase(repo).Execute(ctx, 1, "password1")
+		},
+		"update": func(ctx context.Context) error {
+			_, err := NewUpdatePersonUseCase(repo, nil, bus).Execute(ctx, 1, "x", 0)
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			return NewDeletePersonUseCase(repo, bus).Execute(ctx, 1)
+		},
+		"restore": func(ctx context.Context) error {
+			return NewRestorePersonUseCase(repo, bus).Execute(ctx, 1)
+		},
+	}
+
+	callers := map[string]context.Context{
+		"no actor": context.Background(),
+		"student":  entity.WithActor(context.Background(), entity.Actor{PersonId: 2, Role: entity.StudentRole}),
+		"teacher":  entity.WithActor(context.Background(), entity.Actor{PersonId: 3, Role: entity.TeacherRole}),
+	}
+
+	for name, call := range calls {
+		for caller, ctx := range callers {
+			if err := call(ctx); !errors.Is(err, entity.ErrPermissionDenied) {
+				t.Errorf("%s by %s: got %v, want %v", name, caller, err, entity.ErrPermissionDenied)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package person
+
+import (
+	"context"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type ChangePasswordUseCase struct {
+	personRepo repository.PersonRepositroy
+	lockout    Lockout
+}
+
+func NewChangePasswordUseCase(
+	personRepo repository.PersonRepositroy,
+	lockout Lockout,
+) *ChangePasswordUseCase {
+	return &ChangePasswordUseCase{
+		personRepo: personRepo,
+		lockout:    lockout,
+	}
+}
+
+// Execute replaces the password of a person who knows the current one.
+// Wrong current passwords count towards the lockout like failed logins.
+func (uc *ChangePasswordUseCase) Execute(ctx context.Context, personId uint, oldPassword, newPassword string) error {
+	person, err := uc.personRepo.GetPersonByID(ctx, personId)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyPassword(ctx, uc.personRepo, uc.lockout, person, oldPassword); err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return uc.personRepo.SetPasswordHash(ctx, personId, hash)
+}
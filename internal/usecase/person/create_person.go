@@ -3,22 +3,61 @@ package person
 // Application Layer (Application Business Rules)
 
 import (
+	"context"
+
+	"fmt"
+	"strings"
+
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 )
 
 type CreatePersonUseCase struct {
 	personRepo repository.PersonRepositroy
+	publisher  event.Publisher
 }
 
 func NewCreatePersonUseCase(
 	personRepo repository.PersonRepositroy,
+	publisher event.Publisher,
 ) *CreatePersonUseCase {
 	return &CreatePersonUseCase{
 		personRepo: personRepo,
+		publisher:  publisher,
 	}
 }
 
-func (uc *CreatePersonUseCase) Execute(p entity.Person) uint {
-	return uc.personRepo.CreatePerson(&p)
+// Execute creates p, with password as its login password unless it is
+// empty. Only admins may do this.
+func (uc *CreatePersonUseCase) Execute(ctx context.Context, p entity.Person, password string) (uint, error) {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return 0, fmt.Errorf("%w: only admins can create persons", entity.ErrPermissionDenied)
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		return 0, fmt.Errorf("%w: name is required", entity.ErrInvalidPerson)
+	}
+	if p.Role != entity.StudentRole && p.Role != entity.TeacherRole && p.Role != entity.AdminRole {
+		return 0, fmt.Errorf("%w: unknown role %q", entity.ErrInvalidPerson, p.Role)
+	}
+
+	if password != "" {
+		hash, err := hashPassword(password)
+		if err != nil {
+			return 0, err
+		}
+		p.PasswordHash = hash
+	}
+
+	personId, err := uc.personRepo.CreatePerson(ctx, &p)
+	if err != nil {
+		return 0, err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{
+		Type:     event.PersonCreated,
+		PersonId: personId,
+		SchoolId: p.School.Id,
+	})
+	return personId, nil
 }
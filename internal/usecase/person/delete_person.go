@@ -0,0 +1,44 @@
+package person
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type DeletePersonUseCase struct {
+	personRepo repository.PersonRepositroy
+	publisher  event.Publisher
+}
+
+func NewDeletePersonUseCase(
+	personRepo repository.PersonRepositroy,
+	publisher event.Publisher,
+) *DeletePersonUseCase {
+	return &DeletePersonUseCase{
+		personRepo: personRepo,
+		publisher:  publisher,
+	}
+}
+
+// Execute deletes a person who doesn't teach any class, only admins may
+// do this and not to themselves.
+func (uc *DeletePersonUseCase) Execute(ctx context.Context, personId uint) error {
+	actor, ok := entity.ActorFromContext(ctx)
+	switch {
+	case !ok || actor.Role != entity.AdminRole:
+		return fmt.Errorf("%w: only admins can delete persons", entity.ErrPermissionDenied)
+	case actor.PersonId == personId:
+		return fmt.Errorf("%w: you can't delete yourself", entity.ErrPermissionDenied)
+	}
+
+	if err := uc.personRepo.DeletePerson(ctx, personId); err != nil {
+		return err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{Type: event.PersonDeleted, PersonId: personId})
+	return nil
+}
@@ -1,40 +1,60 @@
 package person
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 )
 
 type EnrollInSchoolStudentUseCase struct {
 	personRepo repository.PersonRepositroy
 	schoolReop repository.SchoolRepository
+	publisher  event.Publisher
 }
 
 func NewEnrollInSchoolStudentUseCase(
 	personRepo repository.PersonRepositroy,
 	schoolReop repository.SchoolRepository,
+	publisher event.Publisher,
 ) *EnrollInSchoolStudentUseCase {
 	return &EnrollInSchoolStudentUseCase{
 		personRepo: personRepo,
 		schoolReop: schoolReop,
+		publisher:  publisher,
 	}
 }
 
 func (uc *EnrollInSchoolStudentUseCase) Execute(
+	ctx context.Context,
 	studentName,
 	schoolName string,
 ) error {
-	school, err := uc.schoolReop.GetSchoolByName(schoolName)
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can enroll students", entity.ErrPermissionDenied)
+	}
+
+	school, err := uc.schoolReop.GetSchoolByName(ctx, schoolName)
 	if err != nil {
 		return err
 	}
 
-	uc.personRepo.CreatePerson(
+	personId, err := uc.personRepo.CreatePerson(ctx,
 		&entity.Person{
 			Name:   studentName,
 			Role:   entity.StudentRole,
 			School: *school,
 		})
+	if err != nil {
+		return err
+	}
 
+	uc.publisher.Publish(ctx, event.Event{
+		Type:     event.PersonCreated,
+		PersonId: personId,
+		SchoolId: school.Id,
+	})
 	return nil
 }
@@ -1,6 +1,9 @@
 package person
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 )
@@ -17,7 +20,29 @@ func NewListPersonsUseCase(
 	}
 }
 
-func (uc *ListPersonsUseCase) Execute() (*[]entity.Person, error) {
-	return uc.personRepo.GetAllPersons()
+func (uc *ListPersonsUseCase) Execute(ctx context.Context, opts repository.PersonListOptions) (*[]entity.Person, string, error) {
+	if err := uc.authorize(ctx, opts); err != nil {
+		return nil, "", err
+	}
+	return uc.personRepo.GetAllPersons(ctx, opts)
+}
+
+// ExecuteInBatches calls fn with the same items as Execute, batchSize at a
+// time, so they don't all have to fit in memory.
+func (uc *ListPersonsUseCase) ExecuteInBatches(ctx context.Context, opts repository.PersonListOptions, batchSize int, fn func(*[]entity.Person) error) error {
+	if err := uc.authorize(ctx, opts); err != nil {
+		return err
+	}
+	return uc.personRepo.GetPersonsInBatches(ctx, opts, batchSize, fn)
 }
 
+// authorize only lets admins see deleted persons.
+func (uc *ListPersonsUseCase) authorize(ctx context.Context, opts repository.PersonListOptions) error {
+	if !opts.IncludeDeleted {
+		return nil
+	}
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can list deleted persons", entity.ErrPermissionDenied)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package person
+
+import (
+	"context"
+	"errors"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type LoginUseCase struct {
+	personRepo repository.PersonRepositroy
+	lockout    Lockout
+}
+
+func NewLoginUseCase(
+	personRepo repository.PersonRepositroy,
+	lockout Lockout,
+) *LoginUseCase {
+	return &LoginUseCase{
+		personRepo: personRepo,
+		lockout:    lockout,
+	}
+}
+
+// Execute returns the person if password is theirs, and
+// entity.ErrInvalidCredentials if the person doesn't exist, has no
+// password or it doesn't match. Locked persons get
+// entity.ErrAccountLocked whatever the password.
+func (uc *LoginUseCase) Execute(ctx context.Context, personId uint, password string) (*entity.Person, error) {
+	person, err := uc.personRepo.GetPersonByID(ctx, personId)
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		return nil, err
+	}
+
+	if err := verifyPassword(ctx, uc.personRepo, uc.lockout, person, password); err != nil {
+		return nil, err
+	}
+	return person, nil
+}
@@ -0,0 +1,198 @@
+package person
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (r memPersons) RecordFailedLogin(ctx context.Context, personId uint) (int, error) {
+	p := r.persons[personId]
+	p.FailedLogins++
+	return p.FailedLogins, nil
+}
+
+func (r memPersons) LockPerson(ctx context.Context, personId uint, until time.Time) error {
+	r.persons[personId].LockedUntil = until
+	return nil
+}
+
+func (r memPersons) ResetFailedLogins(ctx context.Context, personId uint) error {
+	r.persons[personId].FailedLogins = 0
+	return nil
+}
+
+func (r memPersons) SetPasswordHash(ctx context.Context, personId uint, hash string) error {
+	p := r.persons[personId]
+	p.PasswordHash = hash
+	p.FailedLogins = 0
+	p.LockedUntil = time.Time{}
+	return nil
+}
+
+func mustHash(t *testing.T, password string, cost int) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func TestLogin(t *testing.T) {
+	hash := mustHash(t, "password1", bcrypt.MinCost)
+
+	tests := []struct {
+		name     string
+		person   *entity.Person
+		password string
+		err      error
+	}{
+		{name: "right password", person: &entity.Person{PasswordHash: hash}, password: "password1"},
+		{name: "wrong password", person: &entity.Person{PasswordHash: hash}, password: "password2", err: entity.ErrInvalidCredentials},
+		{name: "unknown person", password: "password1", err: entity.ErrInvalidCredentials},
+		{name: "no password", person: &entity.Person{}, password: "", err: entity.ErrInvalidCredentials},
+		{
+			name:     "locked",
+			person:   &entity.Person{PasswordHash: hash, LockedUntil: time.Now().Add(time.Hour)},
+			password: "password1",
+			err:      entity.ErrAccountLocked,
+		},
+		{
+			name:     "lock expired",
+			person:   &entity.Person{PasswordHash: hash, LockedUntil: time.Now().Add(-time.Second)},
+			password: "password1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memPersons{persons: map[uint]*entity.Person{}}
+			if tt.person != nil {
+				tt.person.Id = 1
+				repo.persons[1] = tt.person
+			}
+
+			person, err := NewLoginUseCase(repo, Lockout{MaxAttempts: 3, Duration: time.Hour}).
+				Execute(context.Background(), 1, tt.password)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if err == nil && person.Id != 1 {
+				t.Fatalf("logged in as %d, want 1", person.Id)
+			}
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	repo := memPersons{persons: map[uint]*entity.Person{
+		1: {Id: 1, PasswordHash: mustHash(t, "password1", bcrypt.MinCost)},
+	}}
+	lockout := Lockout{MaxAttempts: 3, Duration: 100 * time.Millisecond}
+	uc := NewLoginUseCase(repo, lockout)
+	ctx := context.Background()
+
+	login := func(password string, want error) {
+		t.Helper()
+		if _, err := uc.Execute(ctx, 1, password); !errors.Is(err, want) {
+			t.Fatalf("login with %q: got %v, want %v", password, err, want)
+		}
+	}
+
+	// a right password resets the count
+	login("wrong", entity.ErrInvalidCredentials)
+	login("wrong", entity.ErrInvalidCredentials)
+	login("password1", nil)
+	if failed := repo.persons[1].FailedLogins; failed != 0 {
+		t.Fatalf("%d failed logins after a successful one", failed)
+	}
+
+	login("wrong", entity.ErrInvalidCredentials)
+	login("wrong", entity.ErrInvalidCredentials)
+	login("wrong", entity.ErrInvalidCredentials)
+	login("password1", entity.ErrAccountLocked)
+	login("wrong", entity.ErrAccountLocked)
+
+	time.Sleep(lockout.Duration)
+	login("password1", nil)
+	if failed := repo.persons[1].FailedLogins; failed != 0 {
+		t.Fatalf("%d failed logins after the lock expired and a successful login", failed)
+	}
+}
+
+func TestChangePasswordCountsTowardsLockout(t *testing.T) {
+	repo := memPersons{persons: map[uint]*entity.Person{
+		1: {Id: 1, PasswordHash: mustHash(t, "password1", bcrypt.MinCost)},
+	}}
+	uc := NewChangePasswordUseCase(repo, Lockout{MaxAttempts: 2, Duration: time.Hour})
+	ctx := context.Background()
+
+	for _, want := range []error{entity.ErrInvalidCredentials, entity.ErrInvalidCredentials, entity.ErrAccountLocked} {
+		if err := uc.Execute(ctx, 1, "wrong", "password2"); !errors.Is(err, want) {
+			t.Fatalf("got %v, want %v", err, want)
+		}
+	}
+}
+
+// Logins of persons that don't exist or have no password compare against
+// a dummy hash, so they take about as long as a wrong password.
+func TestLoginTiming(t *testing.T) {
+	repo := memPersons{persons: map[uint]*entity.Person{
+		1: {Id: 1, PasswordHash: mustHash(t, "password1", bcrypt.DefaultCost)},
+		2: {Id: 2},
+	}}
+	uc := NewLoginUseCase(repo, Lockout{})
+
+	timeLogin := func(personId uint) time.Duration {
+		start := time.Now()
+		if _, err := uc.Execute(context.Background(), personId, "wrong"); !errors.Is(err, entity.ErrInvalidCredentials) {
+			t.Fatalf("login as %d: got %v", personId, err)
+		}
+		return time.Since(start)
+	}
+
+	wrong := timeLogin(1)
+	for _, personId := range []uint{2, 3} {
+		if d := timeLogin(personId); d < wrong/2 {
+			t.Errorf("login as %d took %v, a wrong password %v", personId, d, wrong)
+		}
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	tests := []struct {
+		password string
+		err      error
+	}{
+		{password: "short", err: entity.ErrInvalidPerson},
+		{password: "ééééééé", err: entity.ErrInvalidPerson},
+		{password: "éééééééé"},
+		{password: "password1"},
+		{password: strings.Repeat("x", 72)},
+		{password: strings.Repeat("x", 73), err: entity.ErrInvalidPerson},
+	}
+
+	for _, tt := range tests {
+		hash, err := hashPassword(tt.password)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("hashPassword(%q): got %v, want %v", tt.password, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if ok, err := checkPassword(hash, tt.password); !ok || err != nil {
+			t.Errorf("checkPassword of %q against its hash: %v, %v", tt.password, ok, err)
+		}
+		if ok, _ := checkPassword(hash, "y"+tt.password); ok {
+			t.Errorf("checkPassword matched %q with another password", tt.password)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package person
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+// dummyHash is compared against when there is no hash to check, so a
+// login takes as long whether or not the person exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+
+func hashPassword(password string) (string, error) {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return "", fmt.Errorf("%w: password must be at least %d characters",
+			entity.ErrInvalidPerson, minPasswordLength)
+	}
+	// bcrypt ignores everything after 72 bytes
+	if len(password) > 72 {
+		return "", fmt.Errorf("%w: password must be at most 72 bytes", entity.ErrInvalidPerson)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether password matches hash. An empty hash
+// never matches but takes as long to check as one that doesn't.
+func checkPassword(hash, password string) (bool, error) {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check password: %w", err)
+	}
+	return true, nil
+}
+
+// Lockout locks a person out for Duration after MaxAttempts wrong
+// passwords in a row. A zero MaxAttempts disables it.
+type Lockout struct {
+	MaxAttempts int
+	Duration    time.Duration
+}
+
+// verifyPassword checks password against person, which is nil if they
+// don't exist, and counts wrong ones towards the lockout.
+func verifyPassword(
+	ctx context.Context,
+	personRepo repository.PersonRepositroy,
+	lockout Lockout,
+	person *entity.Person,
+	password string,
+) error {
+	if person != nil && time.Now().Before(person.LockedUntil) {
+		return fmt.Errorf("%w until %s", entity.ErrAccountLocked,
+			person.LockedUntil.Format(time.RFC3339))
+	}
+
+	var hash string
+	if person != nil {
+		hash = person.PasswordHash
+	}
+
+	ok, err := checkPassword(hash, password)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if person.FailedLogins > 0 {
+			return personRepo.ResetFailedLogins(ctx, person.Id)
+		}
+		return nil
+	}
+	if hash == "" {
+		return entity.ErrInvalidCredentials
+	}
+
+	failed, err := personRepo.RecordFailedLogin(ctx, person.Id)
+	if err != nil {
+		return err
+	}
+	if lockout.MaxAttempts > 0 && failed >= lockout.MaxAttempts {
+		if err := personRepo.LockPerson(ctx, person.Id, time.Now().Add(lockout.Duration)); err != nil {
+			return err
+		}
+	}
+	return entity.ErrInvalidCredentials
+}
@@ -1,10 +1,19 @@
 package person
 
 type PersonUsecases struct {
-	CreateUseCase  *CreatePersonUseCase
-	ListUseCase    *ListPersonsUseCase
-	WhoAmIUseCase  *WhoAmIUseCase
-	EnrollUseCase  *EnrollInSchoolStudentUseCase
+	CreateUseCase *CreatePersonUseCase
+	ListUseCase   *ListPersonsUseCase
+	WhoAmIUseCase *WhoAmIUseCase
+	EnrollUseCase *EnrollInSchoolStudentUseCase
+	LoginUseCase  *LoginUseCase
+
+	SetPasswordUseCase    *SetPasswordUseCase
+	ChangePasswordUseCase *ChangePasswordUseCase
+	ResetPasswordUseCase  *ResetPasswordUseCase
+
+	UpdateUseCase  *UpdatePersonUseCase
+	DeleteUseCase  *DeletePersonUseCase
+	RestoreUseCase *RestorePersonUseCase
 }
 
 func NewPersonUseCases(
@@ -12,12 +21,27 @@ func NewPersonUseCases(
 	listUseCase *ListPersonsUseCase,
 	whoAmIUseCase *WhoAmIUseCase,
 	enrollUseCase *EnrollInSchoolStudentUseCase,
+	loginUseCase *LoginUseCase,
+	setPasswordUseCase *SetPasswordUseCase,
+	changePasswordUseCase *ChangePasswordUseCase,
+	resetPasswordUseCase *ResetPasswordUseCase,
+	updateUseCase *UpdatePersonUseCase,
+	deleteUseCase *DeletePersonUseCase,
+	restoreUseCase *RestorePersonUseCase,
 ) *PersonUsecases {
 	return &PersonUsecases{
 		CreateUseCase: createUseCase,
 		ListUseCase:   listUseCase,
 		WhoAmIUseCase: whoAmIUseCase,
 		EnrollUseCase: enrollUseCase,
+		LoginUseCase:  loginUseCase,
+
+		SetPasswordUseCase:    setPasswordUseCase,
+		ChangePasswordUseCase: changePasswordUseCase,
+		ResetPasswordUseCase:  resetPasswordUseCase,
+
+		UpdateUseCase:  updateUseCase,
+		DeleteUseCase:  deleteUseCase,
+		RestoreUseCase: restoreUseCase,
 	}
 }
-
@@ -0,0 +1,35 @@
+package person
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type ResetPasswordUseCase struct {
+	personRepo repository.PersonRepositroy
+}
+
+func NewResetPasswordUseCase(
+	personRepo repository.PersonRepositroy,
+) *ResetPasswordUseCase {
+	return &ResetPasswordUseCase{
+		personRepo: personRepo,
+	}
+}
+
+// Execute replaces the password of a person and unlocks them, only
+// admins may do this.
+func (uc *ResetPasswordUseCase) Execute(ctx context.Context, personId uint, password string) error {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can reset passwords", entity.ErrPermissionDenied)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return uc.personRepo.SetPasswordHash(ctx, personId, hash)
+}
@@ -0,0 +1,39 @@
+package person
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type RestorePersonUseCase struct {
+	personRepo repository.PersonRepositroy
+	publisher  event.Publisher
+}
+
+func NewRestorePersonUseCase(
+	personRepo repository.PersonRepositroy,
+	publisher event.Publisher,
+) *RestorePersonUseCase {
+	return &RestorePersonUseCase{
+		personRepo: personRepo,
+		publisher:  publisher,
+	}
+}
+
+// Execute brings back a soft deleted person, only admins may do this.
+func (uc *RestorePersonUseCase) Execute(ctx context.Context, personId uint) error {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can restore persons", entity.ErrPermissionDenied)
+	}
+
+	if err := uc.personRepo.RestorePerson(ctx, personId); err != nil {
+		return err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{Type: event.PersonRestored, PersonId: personId})
+	return nil
+}
@@ -0,0 +1,43 @@
+package person
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type SetPasswordUseCase struct {
+	personRepo repository.PersonRepositroy
+}
+
+func NewSetPasswordUseCase(
+	personRepo repository.PersonRepositroy,
+) *SetPasswordUseCase {
+	return &SetPasswordUseCase{
+		personRepo: personRepo,
+	}
+}
+
+// Execute gives a person without a password their first one, only
+// admins may do this.
+func (uc *SetPasswordUseCase) Execute(ctx context.Context, personId uint, password string) error {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can set passwords", entity.ErrPermissionDenied)
+	}
+
+	person, err := uc.personRepo.GetPersonByID(ctx, personId)
+	if err != nil {
+		return err
+	}
+	if person.PasswordHash != "" {
+		return fmt.Errorf("password of person %d: %w", personId, entity.ErrConflict)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return uc.personRepo.SetPasswordHash(ctx, personId, hash)
+}
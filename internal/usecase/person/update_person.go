@@ -0,0 +1,82 @@
+package person
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type UpdatePersonUseCase struct {
+	personRepo repository.PersonRepositroy
+	classRepo  repository.ClassRepository
+	publisher  event.Publisher
+}
+
+func NewUpdatePersonUseCase(
+	personRepo repository.PersonRepositroy,
+	classRepo repository.ClassRepository,
+	publisher event.Publisher,
+) *UpdatePersonUseCase {
+	return &UpdatePersonUseCase{
+		personRepo: personRepo,
+		classRepo:  classRepo,
+		publisher:  publisher,
+	}
+}
+
+// Execute renames a person or moves them to another school, a zero name
+// or schoolId is left unchanged. Only admins may do this. Teachers and
+// students can't leave the school of a class they teach or attend.
+func (uc *UpdatePersonUseCase) Execute(ctx context.Context, personId uint, name string, schoolId uint) (*entity.Person, error) {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return nil, fmt.Errorf("%w: only admins can update persons", entity.ErrPermissionDenied)
+	}
+	if name == "" && schoolId == 0 {
+		return nil, fmt.Errorf("%w: name or school is required", entity.ErrInvalidPerson)
+	}
+	if name != "" && strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: name can't be blank", entity.ErrInvalidPerson)
+	}
+	if schoolId != 0 {
+		if err := uc.checkClasses(ctx, personId, schoolId); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.personRepo.UpdatePerson(ctx, personId, name, schoolId); err != nil {
+		return nil, err
+	}
+	person, err := uc.personRepo.GetPersonByID(ctx, personId)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{
+		Type:     event.PersonUpdated,
+		PersonId: personId,
+		SchoolId: person.School.Id,
+	})
+	return person, nil
+}
+
+// checkClasses makes sure the person stays in the school of every class
+// they teach or attend when moved to schoolId.
+func (uc *UpdatePersonUseCase) checkClasses(ctx context.Context, personId, schoolId uint) error {
+	for _, opts := range []repository.ClassListOptions{{TeacherId: personId}, {StudentId: personId}} {
+		classes, _, err := uc.classRepo.GetAllClasses(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, class := range *classes {
+			if class.SchoolId != schoolId {
+				return fmt.Errorf("person %d is in class %d of school %d, can't move to %d: %w",
+					personId, class.Id, class.SchoolId, schoolId, entity.ErrSchoolMismatch)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package person
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+// memPersons keeps persons in a map, only what UpdatePersonUseCase needs.
+type memPersons struct {
+	repository.PersonRepositroy
+	persons map[uint]*entity.Person
+}
+
+func (r memPersons) GetPersonByID(ctx context.Context, personId uint) (*entity.Person, error) {
+	p, ok := r.persons[personId]
+	if !ok {
+		return nil, entity.ErrNotFound
+	}
+	return p, nil
+}
+
+func (r memPersons) UpdatePerson(ctx context.Context, personId uint, name string, schoolId uint) error {
+	p, ok := r.persons[personId]
+	if !ok {
+		return entity.ErrNotFound
+	}
+	if name != "" {
+		p.Name = name
+	}
+	if schoolId != 0 {
+		p.School.Id = schoolId
+	}
+	return nil
+}
+
+// memClasses filters classes by teacher and student like the store does.
+type memClasses struct {
+	repository.ClassRepository
+	classes []entity.Class
+}
+
+func (r memClasses) GetAllClasses(ctx context.Context, opts repository.ClassListOptions) (*[]entity.Class, string, error) {
+	var classes []entity.Class
+	for _, class := range r.classes {
+		if opts.TeacherId != 0 && class.Teacher.Id != opts.TeacherId {
+			continue
+		}
+		if opts.StudentId != 0 && !attends(class, opts.StudentId) {
+			continue
+		}
+		classes = append(classes, class)
+	}
+	return &classes, "", nil
+}
+
+func attends(class entity.Class, studentId uint) bool {
+	for _, s := range class.Students {
+		if s.Id == studentId {
+			return true
+		}
+	}
+	return false
+}
+
+func TestUpdatePersonKeepsClassMembersInTheirSchool(t *testing.T) {
+	admin := entity.WithActor(context.Background(), entity.Actor{PersonId: 1, Role: entity.AdminRole})
+
+	tests := []struct {
+		name     string
+		personId uint
+		newName  string
+		schoolId uint
+		err      error
+	}{
+		{name: "teacher with a class", personId: 2, schoolId: 20, err: entity.ErrSchoolMismatch},
+		{name: "student in a class", personId: 3, schoolId: 20, err: entity.ErrSchoolMismatch},
+		{name: "teacher stays", personId: 2, schoolId: 10},
+		{name: "rename teacher", personId: 2, newName: "t"},
+		{name: "teacher without classes", personId: 4, schoolId: 20},
+		{name: "student without classes", personId: 5, schoolId: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons := memPersons{persons: map[uint]*entity.Person{
+				2: {Id: 2, Role: entity.TeacherRole, School: entity.School{Id: 10}},
+				3: {Id: 3, Role: entity.StudentRole, School: entity.School{Id: 10}},
+				4: {Id: 4, Role: entity.TeacherRole, School: entity.School{Id: 10}},
+				5: {Id: 5, Role: entity.StudentRole, School: entity.School{Id: 10}},
+			}}
+			classes := memClasses{classes: []entity.Class{{
+				Id:       1,
+				SchoolId: 10,
+				Teacher:  entity.Person{Id: 2},
+				Students: []entity.Person{{Id: 3}},
+			}}}
+			uc := NewUpdatePersonUseCase(persons, classes, event.NewBus())
+
+			before := persons.persons[tt.personId].School.Id
+			person, err := uc.Execute(admin, tt.personId, tt.newName, tt.schoolId)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				if got := persons.persons[tt.personId].School.Id; got != before {
+					t.Fatalf("person moved to school %d although the update failed", got)
+				}
+				return
+			}
+			if tt.schoolId != 0 && person.School.Id != tt.schoolId {
+				t.Fatalf("got school %d, want %d", person.School.Id, tt.schoolId)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package person
 
 import (
+	"context"
+
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 )
@@ -17,7 +19,6 @@ func NewWhoAmIUseCase(
 	}
 }
 
-func (uc *WhoAmIUseCase) Execute(personId uint) (*entity.Person, error) {
-	return uc.personRepo.GetPersonByID(personId)
+func (uc *WhoAmIUseCase) Execute(ctx context.Context, personId uint) (*entity.Person, error) {
+	return uc.personRepo.GetPersonByID(ctx, personId)
 }
-
@@ -0,0 +1,70 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+// Purged counts the records a purge removed.
+type Purged struct {
+	Schools int64
+	Persons int64
+	Classes int64
+}
+
+type PurgeUseCase struct {
+	schoolRepo repository.SchoolRepository
+	classRepo  repository.ClassRepository
+	personRepo repository.PersonRepositroy
+	transactor repository.Transactor
+	// records deleted for longer than this are purged
+	age time.Duration
+}
+
+func NewPurgeUseCase(
+	schoolRepo repository.SchoolRepository,
+	classRepo repository.ClassRepository,
+	personRepo repository.PersonRepositroy,
+	transactor repository.Transactor,
+	age time.Duration,
+) *PurgeUseCase {
+	return &PurgeUseCase{
+		schoolRepo: schoolRepo,
+		classRepo:  classRepo,
+		personRepo: personRepo,
+		transactor: transactor,
+		age:        age,
+	}
+}
+
+// Execute permanently removes the records that were soft deleted more
+// than the configured age ago, only admins may do this. Classes go first
+// so the persons and schools they refer to can follow.
+func (uc *PurgeUseCase) Execute(ctx context.Context) (Purged, error) {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return Purged{}, fmt.Errorf("%w: only admins can purge", entity.ErrPermissionDenied)
+	}
+
+	before := time.Now().Add(-uc.age)
+
+	var purged Purged
+	err := uc.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if purged.Classes, err = uc.classRepo.PurgeClasses(ctx, before); err != nil {
+			return err
+		}
+		if purged.Persons, err = uc.personRepo.PurgePersons(ctx, before); err != nil {
+			return err
+		}
+		purged.Schools, err = uc.schoolRepo.PurgeSchools(ctx, before)
+		return err
+	})
+	if err != nil {
+		return Purged{}, err
+	}
+	return purged, nil
+}
@@ -1,19 +1,45 @@
 package school
 
-import "github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+import (
+	"context"
+
+	"fmt"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
 
 type CreateSchoolUseCase struct {
 	schoolRepo repository.SchoolRepository
+	publisher  event.Publisher
 }
 
 func NewCreateSchoolUseCase(
 	schoolRepo repository.SchoolRepository,
+	publisher event.Publisher,
 ) *CreateSchoolUseCase {
 	return &CreateSchoolUseCase{
 		schoolRepo: schoolRepo,
+		publisher:  publisher,
 	}
 }
 
-func (uc *CreateSchoolUseCase) Execute(schoolName string) uint {
-	return uc.schoolRepo.CreateSchool(schoolName)
+// Execute creates a school, only admins may do this.
+func (uc *CreateSchoolUseCase) Execute(ctx context.Context, schoolName string) (uint, error) {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return 0, fmt.Errorf("%w: only admins can create schools", entity.ErrPermissionDenied)
+	}
+	if strings.TrimSpace(schoolName) == "" {
+		return 0, fmt.Errorf("%w: name is required", entity.ErrInvalidSchool)
+	}
+
+	schoolId, err := uc.schoolRepo.CreateSchool(ctx, schoolName)
+	if err != nil {
+		return 0, err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{Type: event.SchoolCreated, SchoolId: schoolId})
+	return schoolId, nil
 }
@@ -0,0 +1,40 @@
+package school
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type DeleteSchoolUseCase struct {
+	schoolRepo repository.SchoolRepository
+	publisher  event.Publisher
+}
+
+func NewDeleteSchoolUseCase(
+	schoolRepo repository.SchoolRepository,
+	publisher event.Publisher,
+) *DeleteSchoolUseCase {
+	return &DeleteSchoolUseCase{
+		schoolRepo: schoolRepo,
+		publisher:  publisher,
+	}
+}
+
+// Execute deletes a school that no person or class belongs to anymore,
+// only admins may do this.
+func (uc *DeleteSchoolUseCase) Execute(ctx context.Context, schoolId uint) error {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can delete schools", entity.ErrPermissionDenied)
+	}
+
+	if err := uc.schoolRepo.DeleteSchool(ctx, schoolId); err != nil {
+		return err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{Type: event.SchoolDeleted, SchoolId: schoolId})
+	return nil
+}
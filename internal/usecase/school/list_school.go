@@ -1,6 +1,9 @@
 package school
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
 	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
 )
@@ -17,6 +20,29 @@ func NewListSchoolsUseCase(
 	}
 }
 
-func (uc *ListSchoolsUseCase) Execute() (*[]entity.School, error) {
-	return uc.schoolRepo.GetAllSchools()
+func (uc *ListSchoolsUseCase) Execute(ctx context.Context, opts repository.ListOptions) (*[]entity.School, string, error) {
+	if err := uc.authorize(ctx, opts); err != nil {
+		return nil, "", err
+	}
+	return uc.schoolRepo.GetAllSchools(ctx, opts)
+}
+
+// ExecuteInBatches calls fn with the same items as Execute, batchSize at a
+// time, so they don't all have to fit in memory.
+func (uc *ListSchoolsUseCase) ExecuteInBatches(ctx context.Context, opts repository.ListOptions, batchSize int, fn func(*[]entity.School) error) error {
+	if err := uc.authorize(ctx, opts); err != nil {
+		return err
+	}
+	return uc.schoolRepo.GetSchoolsInBatches(ctx, opts, batchSize, fn)
+}
+
+// authorize only lets admins see deleted schools.
+func (uc *ListSchoolsUseCase) authorize(ctx context.Context, opts repository.ListOptions) error {
+	if !opts.IncludeDeleted {
+		return nil
+	}
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can list deleted schools", entity.ErrPermissionDenied)
+	}
+	return nil
 }
@@ -0,0 +1,39 @@
+package school
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type RestoreSchoolUseCase struct {
+	schoolRepo repository.SchoolRepository
+	publisher  event.Publisher
+}
+
+func NewRestoreSchoolUseCase(
+	schoolRepo repository.SchoolRepository,
+	publisher event.Publisher,
+) *RestoreSchoolUseCase {
+	return &RestoreSchoolUseCase{
+		schoolRepo: schoolRepo,
+		publisher:  publisher,
+	}
+}
+
+// Execute brings back a soft deleted school, only admins may do this.
+func (uc *RestoreSchoolUseCase) Execute(ctx context.Context, schoolId uint) error {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return fmt.Errorf("%w: only admins can restore schools", entity.ErrPermissionDenied)
+	}
+
+	if err := uc.schoolRepo.RestoreSchool(ctx, schoolId); err != nil {
+		return err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{Type: event.SchoolRestored, SchoolId: schoolId})
+	return nil
+}
@@ -1,16 +1,25 @@
 package school
 
 type SchoolUsecases struct {
-	CreateUseCase *CreateSchoolUseCase
-	ListUseCase   *ListSchoolsUseCase
+	CreateUseCase  *CreateSchoolUseCase
+	ListUseCase    *ListSchoolsUseCase
+	UpdateUseCase  *UpdateSchoolUseCase
+	DeleteUseCase  *DeleteSchoolUseCase
+	RestoreUseCase *RestoreSchoolUseCase
 }
 
 func NewSchoolUseCases(
 	createUseCase *CreateSchoolUseCase,
 	listUseCase *ListSchoolsUseCase,
+	updateUseCase *UpdateSchoolUseCase,
+	deleteUseCase *DeleteSchoolUseCase,
+	restoreUseCase *RestoreSchoolUseCase,
 ) *SchoolUsecases {
 	return &SchoolUsecases{
-		CreateUseCase: createUseCase,
-		ListUseCase:   listUseCase,
+		CreateUseCase:  createUseCase,
+		ListUseCase:    listUseCase,
+		UpdateUseCase:  updateUseCase,
+		DeleteUseCase:  deleteUseCase,
+		RestoreUseCase: restoreUseCase,
 	}
 }
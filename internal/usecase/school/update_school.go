@@ -0,0 +1,47 @@
+package school
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/event"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type UpdateSchoolUseCase struct {
+	schoolRepo repository.SchoolRepository
+	publisher  event.Publisher
+}
+
+func NewUpdateSchoolUseCase(
+	schoolRepo repository.SchoolRepository,
+	publisher event.Publisher,
+) *UpdateSchoolUseCase {
+	return &UpdateSchoolUseCase{
+		schoolRepo: schoolRepo,
+		publisher:  publisher,
+	}
+}
+
+// Execute renames a school, only admins may do this.
+func (uc *UpdateSchoolUseCase) Execute(ctx context.Context, schoolId uint, name string) (*entity.School, error) {
+	if actor, ok := entity.ActorFromContext(ctx); !ok || actor.Role != entity.AdminRole {
+		return nil, fmt.Errorf("%w: only admins can update schools", entity.ErrPermissionDenied)
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: name is required", entity.ErrInvalidSchool)
+	}
+
+	if err := uc.schoolRepo.UpdateSchool(ctx, schoolId, name); err != nil {
+		return nil, err
+	}
+	school, err := uc.schoolRepo.GetSchoolByID(ctx, schoolId)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publisher.Publish(ctx, event.Event{Type: event.SchoolUpdated, SchoolId: schoolId})
+	return school, nil
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+type SearchUseCase struct {
+	searchRepo repository.SearchRepository
+}
+
+func NewSearchUseCase(
+	searchRepo repository.SearchRepository,
+) *SearchUseCase {
+	return &SearchUseCase{
+		searchRepo: searchRepo,
+	}
+}
+
+// Execute finds the schools, persons and classes whose names start with
+// the words of query. Students can't list persons, so they only search
+// schools and classes.
+func (uc *SearchUseCase) Execute(ctx context.Context, query string, opts repository.SearchOptions) (*[]entity.SearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("%w: query cannot be empty", entity.ErrInvalidQuery)
+	}
+	for _, kind := range opts.Kinds {
+		if !kind.IsValid() {
+			return nil, fmt.Errorf("%w: can't search %q", entity.ErrInvalidQuery, kind)
+		}
+	}
+
+	actor, ok := entity.ActorFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: only persons can search", entity.ErrPermissionDenied)
+	}
+	if actor.Role == entity.StudentRole {
+		kinds, err := studentKinds(opts.Kinds)
+		if err != nil {
+			return nil, err
+		}
+		opts.Kinds = kinds
+	}
+
+	return uc.searchRepo.Search(ctx, query, opts)
+}
+
+// studentKinds drops persons from the kinds a student asked for.
+func studentKinds(kinds []entity.SearchKind) ([]entity.SearchKind, error) {
+	if len(kinds) == 0 {
+		return []entity.SearchKind{entity.SchoolKind, entity.ClassKind}, nil
+	}
+
+	kinds = slices.DeleteFunc(slices.Clone(kinds), func(k entity.SearchKind) bool {
+		return k == entity.PersonKind
+	})
+	if len(kinds) == 0 {
+		return nil, fmt.Errorf("%w: students can't search persons", entity.ErrPermissionDenied)
+	}
+	return kinds, nil
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/entity"
+	"github.com/arashalaei/go-clean-socket-architecture/internal/domain/repository"
+)
+
+// recordedSearch remembers the options it was searched with.
+type recordedSearch struct {
+	opts *repository.SearchOptions
+}
+
+func (r recordedSearch) Search(ctx context.Context, query string, opts repository.SearchOptions) (*[]entity.SearchResult, error) {
+	*r.opts = opts
+	return &[]entity.SearchResult{}, nil
+}
+
+func TestSearchKinds(t *testing.T) {
+	all := []entity.SearchKind{entity.SchoolKind, entity.PersonKind, entity.ClassKind}
+
+	tests := []struct {
+		name  string
+		role  entity.Role
+		kinds []entity.SearchKind
+		want  []entity.SearchKind
+		err   error
+	}{
+		{name: "student everything", role: entity.StudentRole, want: []entity.SearchKind{entity.SchoolKind, entity.ClassKind}},
+		{name: "student all kinds", role: entity.StudentRole, kinds: all, want: []entity.SearchKind{entity.SchoolKind, entity.ClassKind}},
+		{name: "student classes", role: entity.StudentRole, kinds: []entity.SearchKind{entity.ClassKind}, want: []entity.SearchKind{entity.ClassKind}},
+		{name: "student persons", role: entity.StudentRole, kinds: []entity.SearchKind{entity.PersonKind}, err: entity.ErrPermissionDenied},
+		{name: "teacher everything", role: entity.TeacherRole},
+		{name: "teacher persons", role: entity.TeacherRole, kinds: []entity.SearchKind{entity.PersonKind}, want: []entity.SearchKind{entity.PersonKind}},
+		{name: "admin everything", role: entity.AdminRole},
+		{name: "unknown kind", role: entity.AdminRole, kinds: []entity.SearchKind{"teachers"}, err: entity.ErrInvalidQuery},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts repository.SearchOptions
+			uc := NewSearchUseCase(recordedSearch{opts: &opts})
+			ctx := entity.WithActor(context.Background(), entity.Actor{PersonId: 1, Role: tt.role})
+
+			kinds := slices.Clone(tt.kinds)
+			_, err := uc.Execute(ctx, "spring", repository.SearchOptions{Kinds: tt.kinds})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if !slices.Equal(opts.Kinds, tt.want) {
+				t.Fatalf("searched %v, want %v", opts.Kinds, tt.want)
+			}
+			if !slices.Equal(tt.kinds, kinds) {
+				t.Fatalf("the kinds of the caller changed to %v", tt.kinds)
+			}
+		})
+	}
+}
+
+func TestSearchRejects(t *testing.T) {
+	var opts repository.SearchOptions
+	uc := NewSearchUseCase(recordedSearch{opts: &opts})
+	admin := entity.WithActor(context.Background(), entity.Actor{PersonId: 1, Role: entity.AdminRole})
+
+	if _, err := uc.Execute(admin, " ", repository.SearchOptions{}); !errors.Is(err, entity.ErrInvalidQuery) {
+		t.Fatalf("empty query: got %v, want ErrInvalidQuery", err)
+	}
+	if _, err := uc.Execute(context.Background(), "spring", repository.SearchOptions{}); !errors.Is(err, entity.ErrPermissionDenied) {
+		t.Fatalf("search without an actor: got %v, want ErrPermissionDenied", err)
+	}
+}
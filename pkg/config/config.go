@@ -13,10 +13,33 @@ type Config struct {
 }
 
 type ServerConfig struct {
-	Network  string           `mapstructure:"network"`
-	Address  string           `mapstructure:"address"`
-	Timeouts SrvTimeoutConfig `mapstructure:"timeouts"`
-	Limits   SrvLimitConfig   `mapstructure:"limits"`
+	Network   string           `mapstructure:"network"`
+	Address   string           `mapstructure:"address"`
+	Framing   string           `mapstructure:"framing"`
+	Timeouts  SrvTimeoutConfig `mapstructure:"timeouts"`
+	Limits    SrvLimitConfig   `mapstructure:"limits"`
+	Heartbeat HeartbeatConfig  `mapstructure:"heartbeat"`
+	RateLimit RateLimitConfig  `mapstructure:"rate_limit"`
+	Auth      AuthConfig       `mapstructure:"auth"`
+	TLS       TLSConfig        `mapstructure:"tls"`
+	Unix      UnixSocketConfig `mapstructure:"unix"`
+	// Listeners replaces network, address, tls and unix when set
+	Listeners []ListenerConfig `mapstructure:"listeners"`
+}
+
+type ListenerConfig struct {
+	Network  string                `mapstructure:"network"`
+	Address  string                `mapstructure:"address"`
+	TLS      TLSConfig             `mapstructure:"tls"`
+	Unix     UnixSocketConfig      `mapstructure:"unix"`
+	Timeouts ListenerTimeoutConfig `mapstructure:"timeouts"`
+}
+
+// Zero timeouts inherit the server-wide ones.
+type ListenerTimeoutConfig struct {
+	Read  time.Duration `mapstructure:"read"`
+	Write time.Duration `mapstructure:"write"`
+	Idle  time.Duration `mapstructure:"idle"`
 }
 
 type SrvTimeoutConfig struct {
@@ -29,13 +52,19 @@ type SrvTimeoutConfig struct {
 type SrvLimitConfig struct {
 	MaxConnectionsSize int   `mapstructure:"max_connections_size"`
 	MaxMessageSize     int64 `mapstructure:"max_message_size"`
+	MaxInFlight        int   `mapstructure:"max_in_flight"`
 }
 
 type ClientConfig struct {
-	Network  string              `mapstructure:"network"`
-	Address  string              `mapstructure:"address"`
-	Timeouts ClinetTimeoutConfig `mapstructure:"timeouts"`
-	Limits   ClientLimitConfig   `mapstructure:"limits"`
+	Network     string              `mapstructure:"network"`
+	Address     string              `mapstructure:"address"`
+	Framing     string              `mapstructure:"framing"`
+	Codec       string              `mapstructure:"codec"`
+	Compression string              `mapstructure:"compression"`
+	Timeouts    ClinetTimeoutConfig `mapstructure:"timeouts"`
+	Limits      ClientLimitConfig   `mapstructure:"limits"`
+	Heartbeat   HeartbeatConfig     `mapstructure:"heartbeat"`
+	TLS         TLSConfig           `mapstructure:"tls"`
 }
 
 type ClinetTimeoutConfig struct {
@@ -46,13 +75,75 @@ type ClinetTimeoutConfig struct {
 }
 
 type ClientLimitConfig struct {
-	MaxRetries int           `mapstructure:"max_retries"`
-	RetryDelay time.Duration `mapstructure:"retry_delay"`
-	KeepAlive  bool          `mapstructure:"keep_alive"`
+	MaxRetries     int           `mapstructure:"max_retries"`
+	RetryDelay     time.Duration `mapstructure:"retry_delay"`
+	KeepAlive      bool          `mapstructure:"keep_alive"`
+	MaxMessageSize int64         `mapstructure:"max_message_size"`
+}
+
+// A zero interval disables heartbeats.
+type HeartbeatConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+	Misses   int           `mapstructure:"misses"`
+}
+
+type AuthConfig struct {
+	// Required limits connections that haven't logged in to the request
+	// types in Allow and login
+	Required bool     `mapstructure:"required"`
+	Allow    []string `mapstructure:"allow"`
+	// Policy replaces the roles allowed to send the request types it
+	// lists, admins may send anything
+	Policy  map[string][]string `mapstructure:"policy"`
+	Lockout LockoutConfig       `mapstructure:"lockout"`
+}
+
+// Attempts wrong passwords in a row lock a person out for Duration, 0
+// attempts disables the lockout.
+type LockoutConfig struct {
+	Attempts int           `mapstructure:"attempts"`
+	Duration time.Duration `mapstructure:"duration"`
+}
+
+type RateLimitConfig struct {
+	RateLimitsConfig `mapstructure:",squash"`
+	// Types gives request types buckets of their own
+	Types map[string]RateLimitsConfig `mapstructure:"types"`
+}
+
+type RateLimitsConfig struct {
+	Connection RateConfig `mapstructure:"connection"`
+	IP         RateConfig `mapstructure:"ip"`
+	Identity   RateConfig `mapstructure:"identity"`
+}
+
+// Rate is in requests per second, 0 disables the limit.
+type RateConfig struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+type TLSConfig struct {
+	Enabled           bool   `mapstructure:"enabled"`
+	CertFile          string `mapstructure:"cert_file"`
+	KeyFile           string `mapstructure:"key_file"`
+	CAFile            string `mapstructure:"ca_file"`
+	RequireClientCert bool   `mapstructure:"require_client_cert"`
+	ServerName        string `mapstructure:"server_name"`
+}
+
+type UnixSocketConfig struct {
+	// Mode is an octal permission string like "0660"
+	Mode  string `mapstructure:"mode"`
+	User  string `mapstructure:"user"`
+	Group string `mapstructure:"group"`
 }
 
 type DatabaseConfig struct {
 	Path string `mapstructure:"path"`
+	// PurgeAfter is how long soft deleted records are kept before a
+	// purge removes them, zero purges every deleted record
+	PurgeAfter time.Duration `mapstructure:"purge_after"`
 }
 
 func Load(path string) (*Config, error) {